@@ -2,25 +2,47 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"html/template"
+	"io"
 	"math/rand"
 	"math"
+	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 	"unicode"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/text/unicode/norm"
 )
 
 // TongueTwister represents a single tongue twister with its metadata
 type TongueTwister struct {
-	Number   string `json:"number"`
-	Date     string `json:"date"`
-	Text     string `json:"text"`
-	Stats    TwisterStats
-	Score    float64
+	Number     string `json:"number"`
+	Date       string `json:"date"`
+	Text       string `json:"text"`
+	Source     string `json:"source,omitempty"`
+	Author     string `json:"author,omitempty"`
+	Stats      TwisterStats
+	Score      float64
+	Percentile int    // Доля скороговорок корпуса (0-100), которые проще этой
+	Level      string `json:"level,omitempty"` // Уровень сложности по выбранному классификатору (-classify); пересчитывается при каждом анализе
 }
 
 // TwisterStats holds statistical data about a tongue twister
@@ -34,6 +56,10 @@ type TwisterStats struct {
 	DifficultSounds     int    // Количество сложных звуков
 	DifficultCombos     int    // Количество сложных сочетаний
 	SoundComplexityScore float64 // Оценка сложности звуков
+	TransitionDensity   float64 // Среднее число переходов гласная↔согласная на слово
+	MaxConsonantRun     int     // Самая длинная цепочка согласных подряд
+	RepeatedWords       int     // Повторы целых слов (каждое повторение после первого)
+	RepeatedRoots       int     // Разные слова с общим корнем (по первым буквам)
 }
 
 // Difficulty levels
@@ -51,21 +77,52 @@ const (
 	RepeatMode     = "repeat"
 	ChallengeMode  = "challenge"
 	PerfectionMode = "perfection" // New mode for perfection training
+	CumulativeMode = "cumulative" // Build each phrase up word by word
+	SimilarMode    = "similar"    // Walk through textually similar twisters back to back
+	VariationsMode = "variations" // Drill reordered and repeated versions of each twister
+)
+
+// Scripts reported by scriptOf
+const (
+	ScriptCyrillic = "cyrillic"
+	ScriptLatin    = "latin"
+	ScriptMixed    = "mixed"
+	ScriptEmpty    = "empty"
 )
 
 // DictionFocus represents areas to focus on for diction training
 type DictionFocus struct {
+	ID          string // Name accepted by -focus
 	Name        string
 	Description string
 }
 
 // Predefined diction focus areas
 var dictionFocusAreas = []DictionFocus{
-	{Name: "Артикуляция", Description: "Четкое произношение каждого звука"},
-	{Name: "Ритм", Description: "Равномерный темп речи"},
-	{Name: "Ударения", Description: "Правильное ударение в словах"},
-	{Name: "Дыхание", Description: "Контроль дыхания при произношении"},
-	{Name: "Скорость", Description: "Увеличение скорости без потери качества"},
+	{ID: "articulation", Name: "Артикуляция", Description: "Четкое произношение каждого звука"},
+	{ID: "rhythm", Name: "Ритм", Description: "Равномерный темп речи"},
+	{ID: "stress", Name: "Ударения", Description: "Правильное ударение в словах"},
+	{ID: "breathing", Name: "Дыхание", Description: "Контроль дыхания при произношении"},
+	{ID: "speed", Name: "Скорость", Description: "Увеличение скорости без потери качества"},
+}
+
+// parseFocus maps a -focus value to an index in dictionFocusAreas. It accepts a focus ID
+// (case-insensitive) or, for compatibility, a number in range.
+func parseFocus(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	index, numErr := strconv.Atoi(value)
+	if numErr == nil && index >= 0 && index < len(dictionFocusAreas) {
+		return index, nil
+	}
+	
+	ids := make([]string, len(dictionFocusAreas))
+	for i, focus := range dictionFocusAreas {
+		if focus.ID == value {
+			return i, nil
+		}
+		ids[i] = focus.ID
+	}
+	return 0, fmt.Errorf("unknown focus %q, expected one of: %s (or 0-%d)", value, strings.Join(ids, ", "), len(dictionFocusAreas)-1)
 }
 
 // Сложные звуки и сочетания в русском языке
@@ -123,48 +180,515 @@ var (
 
 // UserPerformance хранит статистику выступления пользователя
 type UserPerformance struct {
-	SuccessRate      map[string]float64 // Успешность по типам звуков
-	DifficultyRating map[string]float64 // Субъективная сложность категорий
-	LastScores       []int              // Последние оценки для отслеживания прогресса
-	TotalSessions    int                // Общее количество сессий
-	AverageScore     float64            // Средний балл
+	SuccessRate      map[string]float64 `json:"success_rate"`            // Успешность по типам звуков
+	DifficultyRating map[string]float64 `json:"difficulty_rating"`       // Субъективная сложность категорий
+	LastScores       []int              `json:"last_scores"`             // Последние оценки для отслеживания прогресса
+	TotalSessions    int                `json:"total_sessions"`          // Общее количество сессий
+	AverageScore     float64            `json:"average_score"`           // Средний балл с экспоненциальным затуханием (свежие оценки весомее)
+	ScoreDecay       float64            `json:"-"`                       // Вес каждой предыдущей оценки относительно следующей (0-1]
+	SessionDates     []string           `json:"session_dates,omitempty"` // Дни с тренировками (ГГГГ-ММ-ДД), без повторов
+	MasteredHard     []string           `json:"mastered_hard,omitempty"` // Идентификаторы (twisterID) сложных скороговорок, прочитанных на 5
+	Badges           []Badge            `json:"badges,omitempty"`        // Полученные достижения
+	BestWPM          map[string]float64 `json:"best_wpm,omitempty"`      // Лучший темп (слов в минуту) по номеру скороговорки
 }
 
+// maxStoredScores ограничивает число последних оценок, сохраняемых в файле профиля
+const maxStoredScores = 50
+
+// defaultScoreDecay is the default weight of each older score relative to the next one in AverageScore
+const defaultScoreDecay = 0.7
+
 // NewUserPerformance создает новый объект для отслеживания производительности
 func NewUserPerformance() *UserPerformance {
 	return &UserPerformance{
 		SuccessRate:      make(map[string]float64),
 		DifficultyRating: make(map[string]float64),
+		BestWPM:          make(map[string]float64),
 		LastScores:       make([]int, 0, 10),
 		TotalSessions:    0,
 		AverageScore:     3.0, // Начальное среднее значение
+		ScoreDecay:       defaultScoreDecay,
+	}
+}
+
+// loadUserProfile загружает профиль из JSON-файла; если файла ещё нет, возвращает новый профиль
+func loadUserProfile(path string) (*UserPerformance, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewUserPerformance(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile %s: %w", path, err)
+	}
+	
+	profile := NewUserPerformance()
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	
+	// В старых или отредактированных вручную файлах карты могут отсутствовать
+	if profile.SuccessRate == nil {
+		profile.SuccessRate = make(map[string]float64)
+	}
+	if profile.DifficultyRating == nil {
+		profile.DifficultyRating = make(map[string]float64)
+	}
+	if profile.BestWPM == nil {
+		profile.BestWPM = make(map[string]float64)
+	}
+	return profile, nil
+}
+
+// saveUserProfile сохраняет профиль в JSON-файл, оставляя только последние оценки
+func saveUserProfile(path string, profile *UserPerformance) error {
+	if len(profile.LastScores) > maxStoredScores {
+		profile.LastScores = profile.LastScores[len(profile.LastScores)-maxStoredScores:]
+	}
+	
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write profile %s: %w", path, err)
+	}
+	return nil
+}
+
+// Badge — полученное достижение
+type Badge struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	EarnedAt string `json:"earned_at"` // Дата получения (ГГГГ-ММ-ДД)
+}
+
+// SessionReport описывает итоги одной завершённой сессии
+type SessionReport struct {
+	Date   time.Time     `json:"date"`
+	Focus  string        `json:"focus,omitempty"` // ID фокуса из dictionFocusAreas
+	Rounds []RoundResult `json:"rounds"`
+}
+
+// Achievement — правило получения достижения
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	Earned      func(profile *UserPerformance, session SessionReport) bool
+}
+
+// perfectScore — высшая оценка за раунд
+const perfectScore = 5
+
+// achievements перечисляет все достижения; новые правила добавляются сюда
+var achievements = []Achievement{
+	{
+		ID:          "first-perfect",
+		Name:        "Первая пятёрка",
+		Description: "оценка 5 за раунд",
+		Earned: func(profile *UserPerformance, session SessionReport) bool {
+			for _, round := range session.Rounds {
+				if round.Score == perfectScore {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		ID:          "flawless-session",
+		Name:        "Без единой ошибки",
+		Description: "сессия из 3+ раундов, все на 5",
+		Earned: func(profile *UserPerformance, session SessionReport) bool {
+			if len(session.Rounds) < 3 {
+				return false
+			}
+			for _, round := range session.Rounds {
+				if round.Score != perfectScore {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		ID:          "streak-7",
+		Name:        "Неделя подряд",
+		Description: "тренировки 7 дней подряд",
+		Earned: func(profile *UserPerformance, session SessionReport) bool {
+			return dayStreak(profile.SessionDates, session.Date) >= 7
+		},
+	},
+	{
+		ID:          "hard-10",
+		Name:        "Покоритель сложных",
+		Description: "10 сложных скороговорок прочитаны на 5",
+		Earned: func(profile *UserPerformance, session SessionReport) bool {
+			return len(profile.MasteredHard) >= 10
+		},
+	},
+	{
+		ID:          "sessions-10",
+		Name:        "Десять тренировок",
+		Description: "10 завершённых сессий",
+		Earned: func(profile *UserPerformance, session SessionReport) bool {
+			return profile.TotalSessions >= 10
+		},
+	},
+}
+
+// recordSession заносит в профиль день сессии и сложные скороговорки, прочитанные на 5
+func recordSession(profile *UserPerformance, session SessionReport) {
+	day := session.Date.Format("2006-01-02")
+	if n := len(profile.SessionDates); n == 0 || profile.SessionDates[n-1] != day {
+		profile.SessionDates = append(profile.SessionDates, day)
+	}
+	
+	for _, round := range session.Rounds {
+		level := twisterLevel(round.Twister)
+		if round.Score != perfectScore || (level != Hard && level != Expert) {
+			continue
+		}
+		mastered := false
+		for _, id := range profile.MasteredHard {
+			if id == twisterID(round.Twister) {
+				mastered = true
+				break
+			}
+		}
+		if !mastered {
+			profile.MasteredHard = append(profile.MasteredHard, twisterID(round.Twister))
+		}
+	}
+}
+
+// dayStreak возвращает число дней подряд с тренировками, заканчивающихся днём today
+func dayStreak(dates []string, today time.Time) int {
+	trained := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		trained[date] = true
+	}
+	
+	streak := 0
+	for day := today; trained[day.Format("2006-01-02")]; day = day.AddDate(0, 0, -1) {
+		streak++
+	}
+	return streak
+}
+
+// evaluateAchievements возвращает достижения, впервые заработанные в этой сессии.
+// Профиль должен уже содержать итоги сессии (см. recordSession)
+func evaluateAchievements(profile *UserPerformance, session SessionReport) []Badge {
+	earned := make(map[string]bool, len(profile.Badges))
+	for _, badge := range profile.Badges {
+		earned[badge.ID] = true
+	}
+	
+	var badges []Badge
+	for _, achievement := range achievements {
+		if earned[achievement.ID] || !achievement.Earned(profile, session) {
+			continue
+		}
+		badges = append(badges, Badge{
+			ID:       achievement.ID,
+			Name:     achievement.Name,
+			EarnedAt: session.Date.Format("2006-01-02"),
+		})
+	}
+	return badges
+}
+
+// awardAchievements сохраняет новые достижения в профиле и объявляет о них
+func awardAchievements(profile *UserPerformance, session SessionReport) {
+	recordSession(profile, session)
+	badges := evaluateAchievements(profile, session)
+	if len(badges) == 0 {
+		return
+	}
+	
+	profile.Badges = append(profile.Badges, badges...)
+	fmt.Println()
+	fmt.Println("=== Новые достижения ===")
+	for _, badge := range badges {
+		for _, achievement := range achievements {
+			if achievement.ID == badge.ID {
+				fmt.Printf("- %s — %s\n", badge.Name, achievement.Description)
+			}
+		}
 	}
 }
 
 func main() {
 	// Parse command line flags
-	jsonPathFlag := flag.String("json", "tongue_twisters/all_twisters.json", "Path to JSON file with tongue twisters")
+	jsonPathFlag := flag.String("json", "tongue_twisters/all_twisters.json", "Path to JSON file with tongue twisters; a comma-separated list or glob loads several files")
 	randomCountFlag := flag.Int("count", 5, "How many random tongue twisters to select for training")
 	difficultyFlag := flag.String("difficulty", "all", "Difficulty level (easy, medium, hard, expert, all)")
-	modeFlag := flag.String("mode", "standard", "Training mode (standard, timed, repeat, challenge, perfection)")
+	modeFlag := flag.String("mode", "standard", "Training mode (standard, timed, repeat, challenge, perfection, cumulative, similar, variations)")
 	timePerTwisterFlag := flag.Int("time", 30, "Seconds per tongue twister in timed mode")
 	repetitionsFlag := flag.Int("reps", 3, "Number of repetitions in repeat mode")
-	focusFlag := flag.Int("focus", 0, "Focus area for perfection mode (0-4, see documentation)")
+	focusFlag := flag.String("focus", "articulation", "Focus area for perfection mode: articulation, rhythm, stress, breathing, speed (or 0-4)")
 	perfectionLevelFlag := flag.Int("level", 3, "Perfection level (1-5, higher is more demanding)")
+	roundsFlag := flag.Int("rounds", 0, "Number of rounds in perfection mode for any focus (default: per focus, see -rounds-<focus>)")
+	focusRoundsFlags := []*int{
+		flag.Int("rounds-articulation", 0, "Rounds for articulation focus (default: level + 2)"),
+		flag.Int("rounds-rhythm", 0, "Rounds for rhythm focus (default: level + 2)"),
+		flag.Int("rounds-stress", 0, "Rounds for stress focus (default: level + 2)"),
+		flag.Int("rounds-breathing", 0, "Rounds for breathing focus (default: level + 1)"),
+		flag.Int("rounds-speed", 0, "Rounds for speed focus (default: level + 4)"),
+	}
+	noAdaptFlag := flag.Bool("no-adapt", false, "Keep the generated difficulty progression in perfection mode instead of adjusting it to your scores")
+	targetScoreFlag := flag.Float64("target-score", 0, "Prefer twisters whose difficulty score is closest to this value (overrides -difficulty and -mix)")
+	scoreDecayFlag := flag.Float64("score-decay", defaultScoreDecay, "Weight of each older self-score relative to the next in perfection mode's average (0-1, 1 = plain mean)")
 	mixDifficultyFlag := flag.Bool("mix", true, "Mix different difficulty levels when selecting twisters")
+	coverageFlag := flag.Bool("coverage", false, "Prefer twisters with sounds that are rare in the corpus when picking at random")
+	previewSetFlag := flag.Bool("preview-set", false, "Print the twisters the selection would train, with their stats, and exit")
+	previewOutFlag := flag.String("preview-out", "", "With -preview-set, also write the selected twisters as JSON to this file")
+	cooldownFlag := flag.Int("cooldown", 0, "Finish every session with this many easy twisters, kept out of the main selection")
+	noRepeatFlag := flag.Bool("no-repeat", false, "Within a session, don't repeat a twister until all candidates have been shown (perfection rounds, new challenge sets)")
+	shuffleWithinFlag := flag.Bool("shuffle-within", false, "Order the session from easy to hard, shuffling twisters within each difficulty level")
+	spreadFlag := flag.Bool("spread", false, "With -mix, alternate difficulty levels instead of shuffling them")
+	recognizerFlag := flag.String("recognizer", "", "Shell command that records speech and prints the recognized text; scores perfection rounds automatically")
+	hintsFlag := flag.Int("hints", -1, "Hide round advice in perfection mode and allow revealing it this many times per session (-1 = always show)")
+	resumeSessionFlag := flag.Bool("resume-session", false, "Continue the perfection session interrupted earlier, from the state autosaved next to -profile (or in the user cache directory)")
+	secondAttemptFlag := flag.Bool("second-attempt", false, "Repeat the perfection session with the same twisters and compare scores")
+	profileFlag := flag.String("profile", "", "JSON file to keep your progress between perfection sessions")
+	compareProfilesFlag := flag.String("compare-profiles", "", "Compare two saved profiles side by side (a.json,b.json) and exit")
+	reportDirFlag := flag.String("report-dir", "", "Save a JSON report of every perfection session into this directory")
+	aggregateFlag := flag.String("aggregate", "", "Summarize all session reports in this directory and exit")
+	aggregateCSVFlag := flag.String("aggregate-csv", "", "With -aggregate, also write the average score per day to this CSV file")
+	remindFlag := flag.Bool("remind", false, "Suggest the next practice time from your -profile, print cron and at lines for a reminder, and exit")
+	planFlag := flag.Bool("plan", false, "Print a 7-day practice plan based on your -profile and exit")
+	drillFlag := flag.String("drill", "", "Drill the twister with this number repeatedly, cycling articulation, rhythm and speed focus")
+	transcriptFlag := flag.String("transcript", "", "Write a timeline of the session's events to this file (.csv for CSV, otherwise JSON)")
+	nonInteractiveFlag := flag.Bool("non-interactive", false, "Run the session without waiting for input (for demos and CI)")
+	stepDelayFlag := flag.Duration("step-delay", 0, "Pause used instead of waiting for Enter in non-interactive mode (e.g. 2s)")
+	autoScoreFlag := flag.Int("auto-score", 0, "Self-score filled in automatically in non-interactive mode (1-5, 0 = random)")
+	easyBelowFlag := flag.Float64("easy-below", difficultyThresholds.Easy, "Scores below this are easy")
+	mediumBelowFlag := flag.Float64("medium-below", difficultyThresholds.Medium, "Scores below this (and not easy) are medium")
+	hardBelowFlag := flag.Float64("hard-below", difficultyThresholds.Hard, "Scores below this (and not medium) are hard; the rest are expert")
+	classifyFlag := flag.String("classify", ClassifyScore, "How to assign difficulty levels: score (composite difficulty score) or syllables (total syllable count)")
+	syllableCutoffsFlag := flag.String("syllable-cutoffs", "15,30,45", "With -classify syllables: fewer syllables than the first value are easy, than the second medium, than the third hard; the rest are expert")
+	groupCountsFlag := flag.Bool("group-counts", false, "Print how many twisters are dominated by each sound group before selecting the set")
+	tuneFlag := flag.Bool("tune", false, "Interactively try difficulty thresholds and print the matching flags, then exit")
+	pdfFlag := flag.String("pdf", "", "Write the selected twisters to a printable PDF practice sheet instead of training")
+	pdfFontFlag := flag.String("pdf-font", "", "TrueType font with Cyrillic glyphs for -pdf (default: look for DejaVu Sans or Arial)")
+	soundStatsFlag := flag.String("sound-stats", "", "Write corpus-wide counts of difficult sounds and combinations as JSON to this file (- for stdout) and exit")
+	exportBySoundFlag := flag.String("export-by-sound", "", "Write one JSON file per difficult sound into this directory, grouping twisters by their dominant sound, and exit")
+	dumpAnalyzedFlag := flag.String("dump-analyzed", "", "Write the analyzed twisters with stats, score and level as JSON to this file (- for stdout) and exit")
+	htmlTableFlag := flag.String("html-table", "", "Write all analyzed twisters as an HTML page with a sortable table to this file and exit")
+	baselineFlag := flag.String("baseline", "", "Compare the current analysis with a corpus saved earlier by -dump-analyzed and exit")
+	explainFlag := flag.String("explain", "", "Print the difficulty score breakdown for the twister with this number and exit")
+	validateFlag := flag.Bool("validate", false, "Check that every entry has a number and text, report problems and exit (status 1 if any)")
+	listFlag := flag.Bool("list", false, "Print the numbers of the loaded twisters (respecting -difficulty and word limits), one per line, and exit")
+	minWordsFlag := flag.Int("min-words", 0, "Only use twisters with at least this many words (0 = no limit)")
+	maxWordsFlag := flag.Int("max-words", 0, "Only use twisters with at most this many words (0 = no limit)")
+	normalizeYoFlag := flag.Bool("normalize-yo", false, "Write ё as е in every loaded twister, so texts that spell it either way compare equal")
+	semivowelFlag := flag.String("semivowel", SemivowelConsonant, "How й counts in the consonant/vowel ratio: consonant, vowel or skip")
+	expandDigitsFlag := flag.Bool("expand-digits", false, "Read digits in the text as Russian number words when analyzing difficulty")
+	audienceFlag := flag.String("audience", AudienceAdult, "Who is practicing: adult or child (changes score weights and prefers shorter twisters for children)")
+	excludeFlag := flag.String("exclude", "", "Comma-separated numbers (or generated IDs) of twisters never to use, e.g. 12,345")
+	excludeFileFlag := flag.String("exclude-file", "exclude.json", "JSON array of twister numbers never to use (ignored if the file does not exist)")
+	regexFlag := flag.String("regex", "", "Only use twisters whose text matches this Go regular expression")
+	requireCyrillicFlag := flag.Bool("require-cyrillic", false, "Drop entries whose text is not predominantly Cyrillic before analysis")
+	autoAdvanceFlag := flag.Int("auto-advance", 0, "Move to the next twister after this many seconds in standard mode (0 = wait for Enter)")
+	heatmapFlag := flag.Bool("heatmap", false, "Shade each letter of the twister by how hard it is to pronounce in standard mode (ANSI terminals)")
+	spotlightFlag := flag.Bool("spotlight", false, "Mark difficult consonant combinations inside the twister text in standard mode")
+	serveFlag := flag.String("serve", "", "Serve the analyzed twisters over HTTP on this address (e.g. :8080) instead of training")
 	flag.Parse()
 
 	// Seed the random number generator
-	rand.Seed(time.Now().UnixNano())
+	seed := time.Now().UnixNano()
+	rand.Seed(seed)
+
+	var textPattern *regexp.Regexp
+	if *regexFlag != "" {
+		var err error
+		textPattern, err = regexp.Compile(*regexFlag)
+		if err != nil {
+			fmt.Printf("Неверное регулярное выражение -regex: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	audience := strings.ToLower(*audienceFlag)
+	weights, ok := audienceWeights[audience]
+	if !ok {
+		fmt.Printf("Неизвестная аудитория -audience %q: ожидается %s или %s\n", *audienceFlag, AudienceAdult, AudienceChild)
+		os.Exit(1)
+	}
+	scoreWeights = weights
+
+	difficultyThresholds = DifficultyThresholds{Easy: *easyBelowFlag, Medium: *mediumBelowFlag, Hard: *hardBelowFlag}
+	if !difficultyThresholds.valid() {
+		fmt.Println("Границы сложности должны быть положительными и возрастать: -easy-below < -medium-below < -hard-below")
+		os.Exit(1)
+	}
+	classifier, err := newClassifier(*classifyFlag, *syllableCutoffsFlag)
+	if err != nil {
+		fmt.Printf("Неверный -classify: %v\n", err)
+		os.Exit(1)
+	}
+	difficultyClassifier = classifier
+
+	nonInteractive.Enabled = *nonInteractiveFlag
+	nonInteractive.Delay = *stepDelayFlag
+	nonInteractive.Score = *autoScoreFlag
+
+	// The perfection session state lives next to the profile, so each profile resumes its own session
+	sessionStatePath = sessionStateLocation(*profileFlag)
+
+	// Load the persistent profile, if one is used
+	var profile *UserPerformance
+	if *profileFlag != "" {
+		var err error
+		profile, err = loadUserProfile(*profileFlag)
+		if err != nil {
+			fmt.Printf("Error loading profile: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Compare two saved profiles instead of training
+	if *compareProfilesFlag != "" {
+		paths := strings.Split(*compareProfilesFlag, ",")
+		if len(paths) != 2 {
+			fmt.Println("Укажите два файла профилей через запятую: -compare-profiles a.json,b.json")
+			os.Exit(1)
+		}
+		var profiles [2]*UserPerformance
+		for i, path := range paths {
+			path = strings.TrimSpace(path)
+			if _, err := os.Stat(path); err != nil {
+				fmt.Printf("Error loading profile: %v\n", err)
+				os.Exit(1)
+			}
+			loaded, err := loadUserProfile(path)
+			if err != nil {
+				fmt.Printf("Error loading profile: %v\n", err)
+				os.Exit(1)
+			}
+			paths[i] = path
+			profiles[i] = loaded
+		}
+		compareProfiles(paths[0], profiles[0], paths[1], profiles[1])
+		return
+	}
+
+	// Summarize saved session reports instead of training
+	if *aggregateFlag != "" {
+		reports, err := loadSessionReports(*aggregateFlag)
+		if err != nil {
+			fmt.Printf("Ошибка чтения отчётов: %v\n", err)
+			os.Exit(1)
+		}
+		aggregate := aggregateReports(reports)
+		printAggregate(aggregate)
+		if *aggregateCSVFlag != "" {
+			if err := writeAggregateCSV(*aggregateCSVFlag, aggregate); err != nil {
+				fmt.Printf("Не удалось записать CSV: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Средний балл по дням записан в %s\n", *aggregateCSVFlag)
+		}
+		return
+	}
+
+	// Print a weekly plan instead of training
+	if *planFlag {
+		if profile == nil {
+			profile = NewUserPerformance()
+		}
+		printPlan(generatePlan(profile), *profileFlag)
+		return
+	}
+
+	// Suggest when to practice next instead of training
+	if *remindFlag {
+		if profile == nil {
+			profile = NewUserPerformance()
+		}
+		printReminder(suggestReminder(profile, time.Now()))
+		return
+	}
 
 	// Load and analyze tongue twisters
-	twisters, err := loadTongueTwisters(*jsonPathFlag)
+	normalizeYo = *normalizeYoFlag
+	paths, err := expandJSONPaths(*jsonPathFlag)
+	if err != nil {
+		fmt.Printf("Error loading tongue twisters: %v\n", err)
+		os.Exit(1)
+	}
+	twisters, err := loadMany(paths)
 	if err != nil {
 		fmt.Printf("Error loading tongue twisters: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Check the file's structure instead of training
+	if *validateFlag {
+		problems := validateCorpus(twisters)
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			fmt.Printf("Найдено проблем: %d\n", len(problems))
+			os.Exit(1)
+		}
+		fmt.Printf("Файл в порядке: %d скороговорок\n", len(twisters))
+		return
+	}
+
+	// Drop twisters the user never wants to see
+	excluded, err := loadExcludedNumbers(*excludeFileFlag)
+	if err != nil {
+		fmt.Printf("Ошибка чтения списка исключений: %v\n", err)
+		os.Exit(1)
+	}
+	for _, number := range strings.Split(*excludeFlag, ",") {
+		if number = strings.TrimSpace(number); number != "" {
+			excluded[number] = true
+		}
+	}
+	if len(excluded) > 0 {
+		before := len(twisters)
+		twisters = excludeTwisters(twisters, excluded)
+		fmt.Fprintf(os.Stderr, "Исключено %d скороговорок\n", before-len(twisters))
+	}
+
+	// Drop English or empty entries that would skew the phonetic analysis
+	if *requireCyrillicFlag {
+		var kept []TongueTwister
+		for _, twister := range twisters {
+			if scriptOf(twister.Text) == ScriptCyrillic {
+				kept = append(kept, twister)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Отброшено %d скороговорок не на кириллице\n", len(twisters)-len(kept))
+		twisters = kept
+	}
+
+	// Keep only twisters whose text matches the pattern
+	if textPattern != nil {
+		twisters = filterTwistersByRegex(twisters, textPattern)
+		fmt.Fprintf(os.Stderr, "Под шаблон %s подходит %d скороговорок\n", textPattern, len(twisters))
+	}
+
+	// Nothing below can work without at least one twister
+	if len(twisters) == 0 {
+		fmt.Println("В файле нет скороговорок для тренировки.")
+		os.Exit(1)
+	}
+
 	// Calculate statistics and score for each twister
+	expandDigits = *expandDigitsFlag
+	switch policy := strings.ToLower(*semivowelFlag); policy {
+	case SemivowelConsonant, SemivowelVowel, SemivowelSkip:
+		semivowelPolicy = policy
+	default:
+		fmt.Printf("Неизвестное значение -semivowel %q: ожидается %s, %s или %s\n",
+			*semivowelFlag, SemivowelConsonant, SemivowelVowel, SemivowelSkip)
+		os.Exit(1)
+	}
 	for i := range twisters {
 		analyzeTwister(&twisters[i])
 	}
@@ -174,6 +698,132 @@ func main() {
 		return twisters[i].Score < twisters[j].Score
 	})
 
+	// Compute each twister's percentile within the corpus once
+	for i := range twisters {
+		twisters[i].Percentile = normalizedScore(twisters[i].Score, twisters)
+	}
+
+	// Write the analyzed corpus for other tools instead of training
+	if *dumpAnalyzedFlag != "" {
+		if err := dumpAnalyzed(*dumpAnalyzedFlag, twisters); err != nil {
+			fmt.Printf("Error writing analyzed twisters: %v\n", err)
+			os.Exit(1)
+		}
+		if *dumpAnalyzedFlag != "-" {
+			fmt.Fprintf(os.Stderr, "Записано %d скороговорок в %s\n", len(twisters), *dumpAnalyzedFlag)
+		}
+		return
+	}
+
+	// Write a browsable table of the analyzed corpus instead of training
+	if *htmlTableFlag != "" {
+		if err := writeHTMLTable(*htmlTableFlag, twisters); err != nil {
+			fmt.Printf("Error writing HTML table: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Таблица из %d скороговорок записана в %s\n", len(twisters), *htmlTableFlag)
+		return
+	}
+
+	// Write corpus-wide sound statistics for analysis
+	if *soundStatsFlag != "" {
+		if err := writeSoundStats(*soundStatsFlag, corpusSoundStats(twisters)); err != nil {
+			fmt.Printf("Error writing sound statistics: %v\n", err)
+			os.Exit(1)
+		}
+		if *soundStatsFlag != "-" {
+			fmt.Fprintf(os.Stderr, "Статистика звуков записана в %s\n", *soundStatsFlag)
+		}
+		return
+	}
+
+	// Write a workbook of twisters grouped by their dominant difficult sound
+	if *exportBySoundFlag != "" {
+		if err := exportBySound(*exportBySoundFlag, twisters); err != nil {
+			fmt.Printf("Error exporting twisters: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Calibrate the level thresholds interactively instead of training
+	if *tuneFlag {
+		if _, ok := difficultyClassifier.(ScoreClassifier); !ok {
+			fmt.Println("-tune подбирает границы оценки сложности и работает только с -classify score; для слогов задайте -syllable-cutoffs")
+			os.Exit(1)
+		}
+		runThresholdTuner(twisters)
+		return
+	}
+
+	// Compare scores with an earlier analysis instead of training
+	if *baselineFlag != "" {
+		baseline, err := loadTongueTwisters(*baselineFlag)
+		if err != nil {
+			fmt.Printf("Error loading baseline: %v\n", err)
+			os.Exit(1)
+		}
+		compareWithBaseline(baseline, twisters)
+		return
+	}
+
+	// Explain a single twister's score instead of training
+	if *explainFlag != "" {
+		twister, ok := findTwisterByNumber(twisters, *explainFlag)
+		if !ok {
+			fmt.Printf("Скороговорка №%s не найдена.\n", *explainFlag)
+			os.Exit(1)
+		}
+		explainTwisterScore(twister)
+		return
+	}
+
+	// Drill a single twister instead of selecting a training set
+	if *drillFlag != "" {
+		twister, ok := findTwisterByNumber(twisters, *drillFlag)
+		if !ok {
+			fmt.Printf("Скороговорка №%s не найдена.\n", *drillFlag)
+			os.Exit(1)
+		}
+		drillRounds := *roundsFlag
+		if drillRounds < 1 {
+			drillRounds = len(drillFocusAreas) * 2
+		}
+		printSessionHeader("drill", twisterLevel(twister), 1, seed)
+		startTranscript(*transcriptFlag)
+		runDrillSession(twister, drillRounds)
+		saveTranscript(*transcriptFlag)
+		return
+	}
+
+	// Letter frequencies of the whole corpus decide which sounds count as rare
+	if *coverageFlag {
+		coverageFreqs = soundFrequencies(twisters)
+	}
+
+	// Limit the corpus by phrase length
+	if *minWordsFlag > 0 || *maxWordsFlag > 0 {
+		twisters = filterTwistersByWordCount(twisters, *minWordsFlag, *maxWordsFlag)
+	}
+
+	// Children practice the shorter half of the corpus unless a length limit was chosen explicitly
+	if audience == AudienceChild && *maxWordsFlag == 0 && len(twisters) > 0 {
+		twisters = shorterHalf(twisters)
+		fmt.Fprintf(os.Stderr, "Для детей оставлены короткие скороговорки: %d\n", len(twisters))
+	}
+
+	// Print a plain, machine-readable index of numbers instead of training
+	if *listFlag {
+		listed := twisters
+		if level, ok := difficultyLevelByName(*difficultyFlag); ok {
+			listed = filterTwistersByDifficulty(twisters, level)
+		}
+		for _, number := range sortedNumbers(listed) {
+			fmt.Println(number)
+		}
+		return
+	}
+
 	// Group by difficulty
 	easyTwisters := filterTwistersByDifficulty(twisters, Easy)
 	mediumTwisters := filterTwistersByDifficulty(twisters, Medium)
@@ -182,19 +832,73 @@ func main() {
 
 	// Print statistics
 	fmt.Printf("Загружено %d скороговорок:\n", len(twisters))
-	fmt.Printf("  %s: %d\n", Easy, len(easyTwisters))
-	fmt.Printf("  %s: %d\n", Medium, len(mediumTwisters))
-	fmt.Printf("  %s: %d\n", Hard, len(hardTwisters))
-	fmt.Printf("  %s: %d\n", Expert, len(expertTwisters))
+	levelCounts := map[string]int{
+		Easy:   len(easyTwisters),
+		Medium: len(mediumTwisters),
+		Hard:   len(hardTwisters),
+		Expert: len(expertTwisters),
+	}
+	confidence := bucketConfidence(levelCounts)
+	for _, level := range []string{Easy, Medium, Hard, Expert} {
+		if note, ok := confidence[level]; ok {
+			fmt.Printf("  %s: %d (%s)\n", level, levelCounts[level], note)
+		} else {
+			fmt.Printf("  %s: %d\n", level, levelCounts[level])
+		}
+	}
+	if len(confidence) > 0 {
+		if _, ok := difficultyClassifier.(SyllableClassifier); ok {
+			fmt.Println("Совет: подберите границы уровней под этот корпус с помощью -syllable-cutoffs")
+		} else {
+			fmt.Println("Совет: подберите границы уровней под этот корпус с помощью -tune")
+		}
+	}
 	fmt.Println()
 
+	// Show how much material each sound group has, to help choose a focus
+	if *groupCountsFlag {
+		printGroupCounts(twisters)
+	}
+
+	// Serve the corpus over HTTP instead of running a training session
+	if *serveFlag != "" {
+		if err := runServer(*serveFlag, twisters); err != nil {
+			fmt.Printf("Ошибка HTTP-сервера: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Set the cooldown twisters aside first, so the main selection can't repeat them
+	var cooldownTwisters []TongueTwister
+	if *cooldownFlag < 0 {
+		fmt.Println("Количество скороговорок для заминки (-cooldown) не может быть отрицательным.")
+		os.Exit(1)
+	}
+	if *cooldownFlag > 0 {
+		cooldownTwisters = selectRandomTwisters(easyTwisters, *cooldownFlag)
+		if len(cooldownTwisters) < *cooldownFlag {
+			fmt.Printf("Для заминки нашлось только %d лёгких скороговорок из %d\n", len(cooldownTwisters), *cooldownFlag)
+		}
+		reserved := make(map[string]bool)
+		for _, twister := range cooldownTwisters {
+			reserved[twisterID(twister)] = true
+		}
+		twisters = excludeTwisters(twisters, reserved)
+		easyTwisters = excludeTwisters(easyTwisters, reserved)
+	}
+
 	// Select twisters based on desired difficulty or mixed from all difficulties
 	var trainingTwisters []TongueTwister
 	
-	if *mixDifficultyFlag && strings.ToLower(*difficultyFlag) == "all" {
+	if *targetScoreFlag > 0 {
+		// Fine-grained selection around a target score instead of difficulty buckets
+		trainingTwisters = selectNearScore(twisters, *targetScoreFlag, *randomCountFlag)
+		fmt.Printf("Выбраны скороговорки со сложностью около %.1f\n", *targetScoreFlag)
+	} else if *mixDifficultyFlag && strings.ToLower(*difficultyFlag) == "all" {
 		// Distribute the count among different difficulty levels
 		totalCount := *randomCountFlag
-		trainingTwisters = selectBalancedTwisters(easyTwisters, mediumTwisters, hardTwisters, expertTwisters, totalCount)
+		trainingTwisters = selectBalancedTwisters(easyTwisters, mediumTwisters, hardTwisters, expertTwisters, totalCount, *spreadFlag)
 		fmt.Println("Выбраны скороговорки разной сложности для тренировки")
 	} else {
 		// Traditional selection based on single difficulty
@@ -221,36 +925,439 @@ func main() {
 		trainingTwisters = selectRandomTwisters(selectedTwisters, *randomCountFlag)
 	}
 
-	// Start the training session based on selected mode
-	switch strings.ToLower(*modeFlag) {
-	case TimedMode:
-		runTimedTrainingSession(trainingTwisters, *timePerTwisterFlag)
-	case RepeatMode:
-		runRepeatTrainingSession(trainingTwisters, *repetitionsFlag)
-	case ChallengeMode:
-		runChallengeTrainingSession(trainingTwisters)
-	case PerfectionMode:
-		focusArea := *focusFlag
-		if focusArea < 0 || focusArea >= len(dictionFocusAreas) {
-			focusArea = 0
-		}
-		perfectionLevel := *perfectionLevelFlag
-		if perfectionLevel < 1 || perfectionLevel > 5 {
-			perfectionLevel = 3
-		}
-		runPerfectionTrainingSession(trainingTwisters, focusArea, perfectionLevel)
-	default:
-		runStandardTrainingSession(trainingTwisters)
+	if len(trainingTwisters) == 0 {
+		fmt.Println("Не найдено скороговорок, подходящих под выбранные параметры.")
+		os.Exit(1)
 	}
-}
 
-// loadTongueTwisters loads tongue twisters from a JSON file
-func loadTongueTwisters(jsonPath string) ([]TongueTwister, error) {
-	// Read the JSON file
-	data, err := os.ReadFile(jsonPath)
-	if err != nil {
-		// If the file doesn't exist at the specified path, try to find it in common locations
-		if os.IsNotExist(err) {
+	// Progress from easy to hard, with a fresh order inside each level
+	if *shuffleWithinFlag {
+		sort.SliceStable(trainingTwisters, func(i, j int) bool {
+			ri, rj := levelIndex(twisterLevel(trainingTwisters[i])), levelIndex(twisterLevel(trainingTwisters[j]))
+			if ri != rj {
+				return ri < rj
+			}
+			return trainingTwisters[i].Score < trainingTwisters[j].Score
+		})
+		shuffleWithinLevels(trainingTwisters)
+	}
+
+	// Show the selection instead of training
+	if *previewSetFlag {
+		printPreviewSet(trainingTwisters)
+		if *previewOutFlag != "" {
+			if err := dumpAnalyzed(*previewOutFlag, trainingTwisters); err != nil {
+				fmt.Printf("Error writing the selection: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Выбранные скороговорки записаны в %s\n", *previewOutFlag)
+		}
+		return
+	}
+
+	// Print the selection as a practice sheet instead of training
+	if *pdfFlag != "" {
+		if err := renderPracticeSheet(trainingTwisters, *pdfFlag, *pdfFontFlag); err != nil {
+			fmt.Printf("Не удалось создать PDF: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Лист для тренировки сохранён в %s\n", *pdfFlag)
+		return
+	}
+
+	// Later picks in the session avoid what has already been shown
+	if *noRepeatFlag {
+		sessionSampler = NewSampler()
+	}
+
+	// Start the training session based on selected mode
+	mode := strings.ToLower(*modeFlag)
+	switch mode {
+	case TimedMode, RepeatMode, ChallengeMode, PerfectionMode, CumulativeMode, SimilarMode, VariationsMode:
+	default:
+		mode = StandardMode
+	}
+	
+	// An interrupted perfection session continues with its own twisters and settings
+	var resumeState *SessionState
+	if *resumeSessionFlag {
+		state, err := loadSessionState(sessionStatePath)
+		if err != nil {
+			fmt.Printf("Не удалось продолжить сессию: %v\n", err)
+			os.Exit(1)
+		}
+		resumeState = state
+		trainingTwisters = state.Twisters
+		mode = PerfectionMode
+	}
+	printSessionHeader(mode, *difficultyFlag, len(trainingTwisters), seed)
+	startTranscript(*transcriptFlag)
+	
+	switch mode {
+	case TimedMode:
+		if profile == nil {
+			profile = NewUserPerformance()
+		}
+		runTimedTrainingSession(trainingTwisters, *timePerTwisterFlag, profile)
+		if *profileFlag != "" {
+			if err := saveUserProfile(*profileFlag, profile); err != nil {
+				fmt.Printf("Не удалось сохранить профиль: %v\n", err)
+			}
+		}
+	case RepeatMode:
+		runRepeatTrainingSession(trainingTwisters, *repetitionsFlag)
+	case ChallengeMode:
+		// Fresh sets come from the same difficulty as the first one
+		pool := twisters
+		if level, ok := difficultyLevelByName(*difficultyFlag); ok {
+			pool = filterTwistersByDifficulty(twisters, level)
+		}
+		runChallengeTrainingSession(trainingTwisters, pool)
+	case CumulativeMode:
+		runCumulativeTrainingSession(trainingTwisters)
+	case VariationsMode:
+		runVariationsTrainingSession(trainingTwisters)
+	case SimilarMode:
+		// The first selected twister starts the chain; the rest come from the whole corpus
+		runSimilarTrainingSession(twisters, trainingTwisters[0], len(trainingTwisters))
+	case PerfectionMode:
+		focusArea, err := parseFocus(*focusFlag)
+		if err != nil {
+			fmt.Printf("Неверный фокус -focus: %v\n", err)
+			os.Exit(1)
+		}
+		perfectionLevel := *perfectionLevelFlag
+		if perfectionLevel < 1 || perfectionLevel > 5 {
+			perfectionLevel = 3
+		}
+		scoreDecay := *scoreDecayFlag
+		if scoreDecay <= 0 || scoreDecay > 1 {
+			scoreDecay = defaultScoreDecay
+		}
+		if isFlagSet("rounds") && *roundsFlag < 1 {
+			fmt.Println("Количество раундов (-rounds) должно быть не меньше 1.")
+			os.Exit(1)
+		}
+		rounds := *roundsFlag
+		if rounds == 0 {
+			rounds = *focusRoundsFlags[focusArea]
+			if rounds < 0 {
+				fmt.Println("Количество раундов для фокуса должно быть не меньше 1.")
+				os.Exit(1)
+			}
+		}
+		if profile == nil {
+			profile = NewUserPerformance()
+		}
+		var recognizer Recognizer
+		if *recognizerFlag != "" {
+			recognizer = CommandRecognizer{Command: *recognizerFlag}
+		}
+		opts := PerfectionOptions{
+			FocusArea:     focusArea,
+			Level:         perfectionLevel,
+			Adaptive:      !*noAdaptFlag,
+			ScoreDecay:    scoreDecay,
+			Rounds:        rounds,
+			Profile:       profile,
+			SecondAttempt: *secondAttemptFlag,
+			Recognizer:    recognizer,
+			ReportDir:     *reportDirFlag,
+			Hints:         *hintsFlag,
+		}
+		if resumeState != nil {
+			opts.FocusArea = resumeState.FocusArea
+			opts.Level = resumeState.Level
+			opts.Adaptive = resumeState.Adaptive
+			opts.Rounds = resumeState.TotalRounds
+			if resumeState.ScoreDecay > 0 {
+				opts.ScoreDecay = resumeState.ScoreDecay
+			}
+			opts.Resume = resumeState
+		}
+		runPerfectionTrainingSession(trainingTwisters, opts)
+		if *profileFlag != "" {
+			if err := saveUserProfile(*profileFlag, profile); err != nil {
+				fmt.Printf("Не удалось сохранить профиль: %v\n", err)
+			}
+		}
+	default:
+		runStandardTrainingSession(trainingTwisters, *spotlightFlag, *heatmapFlag, *autoAdvanceFlag)
+	}
+	runCooldown(cooldownTwisters)
+	saveTranscript(*transcriptFlag)
+}
+
+// printSessionHeader prints the same banner at the start of every session, so logs of
+// different runs can be compared
+func printSessionHeader(mode, difficulty string, count int, seed int64) {
+	fmt.Println(strings.Repeat("=", separatorWidth()))
+	fmt.Printf("Режим: %s\n", mode)
+	fmt.Printf("Сложность: %s\n", difficulty)
+	fmt.Printf("Скороговорок: %d\n", count)
+	fmt.Printf("Seed: %d\n", seed)
+	fmt.Printf("Начало: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Println(strings.Repeat("=", separatorWidth()))
+	fmt.Println()
+}
+
+// maxSeparatorWidth is the separator width on terminals that are wide enough
+const maxSeparatorWidth = 60
+
+// currentTerminalWidth caches the terminal width; 0 means not measured yet
+var currentTerminalWidth int
+
+// cachedTerminalWidth returns the terminal width, running terminalWidth only the first time,
+// so printing a separator never spawns stty
+func cachedTerminalWidth() int {
+	if currentTerminalWidth == 0 {
+		currentTerminalWidth = terminalWidth()
+	}
+	return currentTerminalWidth
+}
+
+// terminalWidth measures the terminal width from $COLUMNS or `stty size`, falling back to 80
+// when neither is available (e.g. output is piped or the platform has no stty)
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	if output, err := cmd.Output(); err == nil {
+		if fields := strings.Fields(string(output)); len(fields) == 2 {
+			if columns, err := strconv.Atoi(fields[1]); err == nil && columns > 0 {
+				return columns
+			}
+		}
+	}
+	return 80
+}
+
+// separatorWidth returns the separator width that fits the terminal
+func separatorWidth() int {
+	if width := cachedTerminalWidth(); width < maxSeparatorWidth {
+		return width
+	}
+	return maxSeparatorWidth
+}
+
+// separator returns a horizontal line that fits the terminal
+func separator() string {
+	return strings.Repeat("-", separatorWidth())
+}
+
+// printGuidance prints a formatted line of advice, wrapped at word boundaries to the terminal width
+func printGuidance(format string, args ...interface{}) {
+	fmt.Println(wrapText(fmt.Sprintf(format, args...), cachedTerminalWidth()))
+}
+
+// wrapText breaks text into lines of at most width characters at spaces; longer words stay whole
+func wrapText(text string, width int) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len([]rune(line))+1+len([]rune(word)) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
+// TranscriptEvent is one entry of the session timeline
+type TranscriptEvent struct {
+	Offset float64 `json:"offset_seconds"` // Seconds since the session started
+	Event  string  `json:"event"`          // shown, started, repetition, reading, score or finished
+	Number string  `json:"number"`         // twisterID of the twister the event belongs to
+	Detail string  `json:"detail,omitempty"`
+}
+
+// Transcript collects timestamped session events; a nil Transcript records nothing
+type Transcript struct {
+	start  time.Time
+	Events []TranscriptEvent
+}
+
+// transcript is the timeline of the current session; set by -transcript
+var transcript *Transcript
+
+// startTranscript begins recording the session timeline if a transcript file was requested
+func startTranscript(path string) {
+	if path != "" {
+		transcript = &Transcript{start: time.Now()}
+	}
+}
+
+// saveTranscript writes the recorded timeline, reporting but not failing on errors
+func saveTranscript(path string) {
+	if transcript == nil {
+		return
+	}
+	if err := transcript.Save(path); err != nil {
+		fmt.Printf("Не удалось сохранить хронологию: %v\n", err)
+		return
+	}
+	fmt.Printf("Хронология сессии сохранена в %s\n", path)
+}
+
+// Record adds an event with the time elapsed since the session started
+func (t *Transcript) Record(event, number, detail string) {
+	if t == nil {
+		return
+	}
+	t.Events = append(t.Events, TranscriptEvent{
+		Offset: math.Round(time.Since(t.start).Seconds()*1000) / 1000,
+		Event:  event,
+		Number: number,
+		Detail: detail,
+	})
+}
+
+// Save writes the events as CSV when path ends in .csv and as JSON otherwise
+func (t *Transcript) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+	
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		w := csv.NewWriter(file)
+		w.Write([]string{"offset_seconds", "event", "number", "detail"})
+		for _, e := range t.Events {
+			w.Write([]string{strconv.FormatFloat(e.Offset, 'f', 3, 64), e.Event, e.Number, e.Detail})
+		}
+		w.Flush()
+		return w.Error()
+	}
+	
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t.Events)
+}
+
+// nonInteractive configures scripted sessions (demos, CI) that never block on stdin
+var nonInteractive = struct {
+	Enabled bool
+	Delay   time.Duration // Pause instead of waiting for Enter
+	Score   int           // Self-score to fill in; 0 picks a random score from 1 to 5
+}{}
+
+// waitForEnter waits for the user to press Enter, or just pauses in non-interactive mode
+func waitForEnter() {
+	if nonInteractive.Enabled {
+		time.Sleep(nonInteractive.Delay)
+		fmt.Println()
+		return
+	}
+	readLine()
+}
+
+// stdin is the single reader of standard input. Every prompt goes through it, so a line typed
+// while a timer was running reaches the next prompt instead of an abandoned goroutine.
+var stdin struct {
+	once  sync.Once
+	lines chan string
+}
+
+// stdinLines returns the channel of lines read from standard input; it is closed at EOF
+func stdinLines() <-chan string {
+	stdin.once.Do(func() {
+		stdin.lines = make(chan string)
+		go func() {
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				line, err := reader.ReadString('\n')
+				if err == nil || line != "" {
+					stdin.lines <- line
+				}
+				if err != nil {
+					close(stdin.lines)
+					return
+				}
+			}
+		}()
+	})
+	return stdin.lines
+}
+
+// readLine reads one line of input, or returns an empty string at EOF
+func readLine() string {
+	return <-stdinLines()
+}
+
+// drainPendingInput discards a line that was typed after a timer ran out, so it does not
+// answer the next prompt
+func drainPendingInput() {
+	for {
+		select {
+		case _, ok := <-stdinLines():
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readSelfScore reads a self-score clamped to 1-5, or fills one in automatically in non-interactive mode
+func readSelfScore() int {
+	var score int
+	if nonInteractive.Enabled {
+		time.Sleep(nonInteractive.Delay)
+		score = nonInteractive.Score
+		if score == 0 {
+			score = rand.Intn(5) + 1
+		}
+		fmt.Println(score)
+	} else {
+		score, _ = strconv.Atoi(strings.TrimSpace(readLine()))
+	}
+	
+	if score < 1 {
+		score = 1
+	} else if score > 5 {
+		score = 5
+	}
+	return score
+}
+
+// readChoice reads one line of input, trimmed. In non-interactive mode it returns an empty
+// line, so menus always take their default.
+func readChoice() string {
+	if nonInteractive.Enabled {
+		time.Sleep(nonInteractive.Delay)
+		fmt.Println()
+		return ""
+	}
+	return strings.TrimSpace(readLine())
+}
+
+// isFlagSet reports whether the named flag was given on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// loadTongueTwisters loads tongue twisters from a JSON file
+func loadTongueTwisters(jsonPath string) ([]TongueTwister, error) {
+	// Open the JSON file
+	file, err := os.Open(jsonPath)
+	if err != nil {
+		// If the file doesn't exist at the specified path, try to find it in common locations
+		if os.IsNotExist(err) {
 			altPaths := []string{
 				"all_twisters.json",
 				"../tongue_twisters/all_twisters.json",
@@ -258,7 +1365,7 @@ func loadTongueTwisters(jsonPath string) ([]TongueTwister, error) {
 			}
 
 			for _, path := range altPaths {
-				if data, err = os.ReadFile(path); err == nil {
+				if file, err = os.Open(path); err == nil {
 					jsonPath = path
 					break
 				}
@@ -269,24 +1376,549 @@ func loadTongueTwisters(jsonPath string) ([]TongueTwister, error) {
 			return nil, fmt.Errorf("failed to read file %s: %w", jsonPath, err)
 		}
 	}
+	defer file.Close()
 
 	// Parse JSON
-	var twisters []TongueTwister
-	if err := json.Unmarshal(data, &twisters); err != nil {
+	twisters, err := decodeTwisters(bufio.NewReader(file))
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
+	for i := range twisters {
+		twisters[i].Text = normalizeText(twisters[i].Text)
+	}
 
-	fmt.Printf("Loaded tongue twisters from %s\n", jsonPath)
+	fmt.Fprintf(os.Stderr, "Loaded tongue twisters from %s\n", jsonPath)
 	return twisters, nil
 }
 
+// normalizeYo makes normalizeText write ё as е (set by -normalize-yo)
+var normalizeYo bool
+
+// stressMarks are combining accents used in Russian texts only to mark stress
+var stressMarks = []rune{'\u0300', '\u0301'}
+
+// normalizeText drops stress marks and brings a loaded text to NFC, so that й and ё typed
+// as a base letter plus a combining mark become single letters. With -normalize-yo ё is
+// also written as е.
+func normalizeText(text string) string {
+	// Decompose first, so stress marks precomposed into letters such as ѝ are dropped too
+	text = norm.NFC.String(strings.Map(func(char rune) rune {
+		for _, mark := range stressMarks {
+			if char == mark {
+				return -1
+			}
+		}
+		return char
+	}, norm.NFD.String(text)))
+	
+	if normalizeYo {
+		text = strings.NewReplacer("ё", "е", "Ё", "Е").Replace(text)
+	}
+	return text
+}
+
+// expandJSONPaths splits a comma-separated -json value and expands glob patterns.
+// A plain path is kept as is, so loadTongueTwisters can still look for it in the usual places.
+func expandJSONPaths(value string) ([]string, error) {
+	var paths []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.ContainsAny(part, "*?[") {
+			paths = append(paths, part)
+			continue
+		}
+		matches, err := filepath.Glob(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", part, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", part)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("no JSON file given")
+	}
+	return paths, nil
+}
+
+// loadMany loads several twister files and concatenates them. A twister whose number was
+// already loaded from an earlier file is skipped; a single file is returned as it is, so
+// -validate still sees its duplicates.
+func loadMany(paths []string) ([]TongueTwister, error) {
+	if len(paths) == 1 {
+		return loadTongueTwisters(paths[0])
+	}
+	
+	var all []TongueTwister
+	seen := make(map[string]bool)
+	duplicates := 0
+	for _, path := range paths {
+		twisters, err := loadTongueTwisters(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, twister := range twisters {
+			if seen[twisterID(twister)] {
+				duplicates++
+				continue
+			}
+			seen[twisterID(twister)] = true
+			all = append(all, twister)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Загружено %d скороговорок из %d файлов, пропущено повторов: %d\n", len(all), len(paths), duplicates)
+	return all, nil
+}
+
+// decodeTwisters stream-decodes a JSON array of twisters one element at a time, so the
+// raw file is never held in memory next to the decoded slice
+func decodeTwisters(r io.Reader) ([]TongueTwister, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected a JSON array of twisters, got %v", token)
+	}
+	
+	var twisters []TongueTwister
+	for decoder.More() {
+		var twister TongueTwister
+		if err := decoder.Decode(&twister); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", len(twisters), err)
+		}
+		twisters = append(twisters, twister)
+	}
+	
+	// Consume the closing bracket so truncated files are reported
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return twisters, nil
+}
+
+// ValidationError describes a problem with one entry of the corpus file
+type ValidationError struct {
+	Index   int    // Position of the entry in the JSON array, from 0
+	Number  string // Number of the entry, if it has one
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Number != "" {
+		return fmt.Sprintf("запись %d (№%s): %s", e.Index, e.Number, e.Message)
+	}
+	return fmt.Sprintf("запись %d: %s", e.Index, e.Message)
+}
+
+// validateCorpus checks that every entry has a number and non-empty text and that numbers are unique
+func validateCorpus(twisters []TongueTwister) []ValidationError {
+	var problems []ValidationError
+	firstIndex := make(map[string]int)
+	for i, twister := range twisters {
+		number := strings.TrimSpace(twister.Number)
+		if number == "" {
+			problems = append(problems, ValidationError{Index: i, Message: "нет номера"})
+		} else if first, seen := firstIndex[number]; seen {
+			problems = append(problems, ValidationError{Index: i, Number: number,
+				Message: fmt.Sprintf("номер уже встречался в записи %d", first)})
+		} else {
+			firstIndex[number] = i
+		}
+		if strings.TrimSpace(twister.Text) == "" {
+			problems = append(problems, ValidationError{Index: i, Number: number, Message: "пустой текст"})
+		}
+	}
+	return problems
+}
+
+// dumpAnalyzed writes the analyzed twisters as indented JSON to path, or to stdout when path is "-"
+func dumpAnalyzed(path string, twisters []TongueTwister) error {
+	data, err := json.MarshalIndent(twisters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode twisters: %w", err)
+	}
+	
+	if path == "-" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// htmlTableTemplate is the page written by -html-table. Clicking a column header sorts the rows
+// by that column, numerically where the cells hold numbers; clicking again reverses the order.
+var htmlTableTemplate = template.Must(template.New("table").Funcs(template.FuncMap{
+	"id": twisterID,
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Скороговорки: анализ сложности</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; font-size: 14px; }
+th, td { border: 1px solid #ccc; padding: 4px 6px; vertical-align: top; }
+th { background: #f0f0f0; cursor: pointer; position: sticky; top: 0; white-space: nowrap; }
+td.num { text-align: right; white-space: nowrap; }
+</style>
+</head>
+<body>
+<h1>Скороговорки: анализ сложности</h1>
+<p>Скороговорок: {{len .}}. Нажмите на заголовок столбца, чтобы отсортировать по нему.</p>
+<table id="twisters">
+<thead><tr>
+<th>Номер</th><th>Текст</th><th>Слов</th><th>Букв</th><th>Гласных</th><th>Согласных</th>
+<th>Уникальных букв</th><th>Повторов букв</th><th>Сложных звуков</th><th>Сложных сочетаний</th>
+<th>Сложность звуков</th><th>Переходов на слово</th><th>Цепочка согласных</th><th>Повторов слов</th>
+<th>Повторов корней</th><th>Оценка</th><th>Процентиль</th><th>Уровень</th>
+</tr></thead>
+<tbody>
+{{range .}}<tr>
+<td>{{id .}}</td><td>{{.Text}}</td><td class="num">{{.Stats.WordCount}}</td><td class="num">{{.Stats.CharCount}}</td>
+<td class="num">{{.Stats.VowelCount}}</td><td class="num">{{.Stats.ConsonantCount}}</td><td class="num">{{.Stats.UniqueChars}}</td>
+<td class="num">{{.Stats.RepeatChars}}</td><td class="num">{{.Stats.DifficultSounds}}</td><td class="num">{{.Stats.DifficultCombos}}</td>
+<td class="num">{{printf "%.2f" .Stats.SoundComplexityScore}}</td><td class="num">{{printf "%.2f" .Stats.TransitionDensity}}</td>
+<td class="num">{{.Stats.MaxConsonantRun}}</td><td class="num">{{.Stats.RepeatedWords}}</td><td class="num">{{.Stats.RepeatedRoots}}</td>
+<td class="num">{{printf "%.2f" .Score}}</td><td class="num">{{.Percentile}}</td><td>{{.Level}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("#twisters th").forEach(function (th, column) {
+  th.addEventListener("click", function () {
+    var tbody = document.querySelector("#twisters tbody");
+    var rows = Array.prototype.slice.call(tbody.rows);
+    var ascending = th.dataset.order !== "asc";
+    th.parentNode.querySelectorAll("th").forEach(function (other) { delete other.dataset.order; });
+    th.dataset.order = ascending ? "asc" : "desc";
+    rows.sort(function (a, b) {
+      var x = a.cells[column].textContent, y = b.cells[column].textContent;
+      var nx = parseFloat(x), ny = parseFloat(y);
+      var result = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y, "ru");
+      return ascending ? result : -result;
+    });
+    rows.forEach(function (row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// writeHTMLTable writes the analyzed twisters as a standalone HTML page with a sortable table
+func writeHTMLTable(path string, twisters []TongueTwister) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+	
+	writer := bufio.NewWriter(file)
+	if err := htmlTableTemplate.Execute(writer, twisters); err != nil {
+		return fmt.Errorf("failed to render the table: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// compareWithBaseline prints how the analysis changed since a baseline saved by -dump-analyzed:
+// the mean score of each and how many twisters, matched by number, moved to another level
+func compareWithBaseline(baseline, current []TongueTwister) {
+	currentByNumber := make(map[string]TongueTwister, len(current))
+	for _, twister := range current {
+		currentByNumber[twisterID(twister)] = twister
+	}
+	
+	matched, changed := 0, 0
+	baselineTotal, currentTotal := 0.0, 0.0
+	transitions := make(map[[2]string]int)
+	for _, old := range baseline {
+		twister, ok := currentByNumber[twisterID(old)]
+		if !ok {
+			continue
+		}
+		matched++
+		baselineTotal += old.Score
+		currentTotal += twister.Score
+		
+		// Older dumps may lack the level, so fall back to the current thresholds
+		oldLevel := old.Level
+		if oldLevel == "" {
+			oldLevel = twisterLevel(old)
+		}
+		if oldLevel != twister.Level {
+			changed++
+			transitions[[2]string{oldLevel, twister.Level}]++
+		}
+	}
+	
+	fmt.Println("=== Сравнение с базовым анализом ===")
+	fmt.Printf("Скороговорок: в базовом %d, сейчас %d, совпало по номеру %d\n", len(baseline), len(current), matched)
+	if matched == 0 {
+		return
+	}
+	
+	baselineMean := baselineTotal / float64(matched)
+	currentMean := currentTotal / float64(matched)
+	fmt.Printf("Средний балл: %.2f → %.2f (%+.2f)\n", baselineMean, currentMean, currentMean-baselineMean)
+	fmt.Printf("Сменили уровень: %d (%.1f%%)\n", changed, float64(changed)/float64(matched)*100)
+	
+	levels := []string{Easy, Medium, Hard, Expert}
+	for _, from := range levels {
+		for _, to := range levels {
+			if n := transitions[[2]string{from, to}]; n > 0 {
+				fmt.Printf("  %s → %s: %d\n", from, to, n)
+			}
+		}
+	}
+}
+
+// pdfFontCandidates are common locations of TrueType fonts with Cyrillic glyphs
+var pdfFontCandidates = []string{
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/TTF/DejaVuSans.ttf",
+	"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+	"/Library/Fonts/Arial Unicode.ttf",
+	"/System/Library/Fonts/Supplemental/Arial.ttf",
+	`C:\Windows\Fonts\arial.ttf`,
+}
+
+// renderPracticeSheet writes the twisters to a PDF, one block per twister with its number,
+// difficulty, text and difficult sounds. The font is embedded so Cyrillic prints everywhere;
+// an empty fontPath picks the first available font from pdfFontCandidates.
+func renderPracticeSheet(twisters []TongueTwister, path, fontPath string) error {
+	if fontPath == "" {
+		for _, candidate := range pdfFontCandidates {
+			if _, err := os.Stat(candidate); err == nil {
+				fontPath = candidate
+				break
+			}
+		}
+		if fontPath == "" {
+			return errors.New("no font with Cyrillic glyphs found, set one with -pdf-font")
+		}
+	}
+	
+	font, err := os.ReadFile(fontPath)
+	if err != nil {
+		return fmt.Errorf("failed to read font: %w", err)
+	}
+	
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("sheet", "", font)
+	pdf.SetTitle("Скороговорки", true)
+	pdf.AddPage()
+	
+	pdf.SetFont("sheet", "", 16)
+	pdf.CellFormat(0, 10, "Скороговорки для тренировки", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+	
+	for _, twister := range twisters {
+		var sounds []string
+		for _, sound := range difficultSounds {
+			if containsAny(strings.ToLower(twister.Text), []rune{sound}) {
+				sounds = append(sounds, string(sound))
+			}
+		}
+		
+		pdf.SetFont("sheet", "", 9)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.CellFormat(0, 5, fmt.Sprintf("№%s · %s (%.1f)", twisterID(twister), twisterLevel(twister), twister.Score), "", 1, "L", false, 0, "")
+		
+		pdf.SetFont("sheet", "", 13)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(0, 6, twister.Text, "", "L", false)
+		
+		if len(sounds) > 0 {
+			pdf.SetFont("sheet", "", 9)
+			pdf.SetTextColor(100, 100, 100)
+			pdf.CellFormat(0, 5, "Сложные звуки: "+strings.Join(sounds, ", "), "", 1, "L", false, 0, "")
+		}
+		pdf.Ln(4)
+	}
+	
+	return pdf.OutputFileAndClose(path)
+}
+
+// dominantDifficultSound returns the difficult sound that occurs most often in the text.
+// A sound only dominates if it occurs at least twice; ties are broken by the order of difficultSounds.
+func dominantDifficultSound(text string) (rune, bool) {
+	counts := make(map[rune]int)
+	for _, char := range strings.ToLower(text) {
+		if isRussianDifficultSound(char) {
+			counts[char]++
+		}
+	}
+	
+	var dominant rune
+	best := 0
+	for _, sound := range difficultSounds {
+		if counts[sound] > best {
+			dominant = sound
+			best = counts[sound]
+		}
+	}
+	return dominant, best >= 2
+}
+
+// exportBySound writes one JSON file per difficult sound into dir with the twisters dominated
+// by that sound, and prints a manifest of the files written
+func exportBySound(dir string, twisters []TongueTwister) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	
+	groups := make(map[rune][]TongueTwister)
+	for _, twister := range twisters {
+		if sound, ok := dominantDifficultSound(twister.Text); ok {
+			groups[sound] = append(groups[sound], twister)
+		}
+	}
+	
+	fmt.Printf("Скороговорки по доминирующему звуку в %s:\n", dir)
+	grouped := 0
+	for _, sound := range difficultSounds {
+		if len(groups[sound]) == 0 {
+			continue
+		}
+		path := filepath.Join(dir, string(sound)+".json")
+		if err := dumpAnalyzed(path, groups[sound]); err != nil {
+			return err
+		}
+		fmt.Printf("  %s: %d\n", path, len(groups[sound]))
+		grouped += len(groups[sound])
+	}
+	fmt.Printf("Без доминирующего звука: %d\n", len(twisters)-grouped)
+	return nil
+}
+
+// SoundCount is how often a sound or combination occurs across the corpus
+type SoundCount struct {
+	Occurrences int `json:"occurrences"`
+	Twisters    int `json:"twisters"` // Twisters containing it at least once
+}
+
+// SoundStats are corpus-wide statistics of difficult sounds and combinations
+type SoundStats struct {
+	Twisters               int                   `json:"twisters"`
+	DifficultSounds        map[string]SoundCount `json:"difficult_sounds"`
+	DifficultCombos        map[string]SoundCount `json:"difficult_combos"`
+	AverageDifficultSounds float64               `json:"average_difficult_sounds"`
+	AverageDifficultCombos float64               `json:"average_difficult_combos"`
+	AverageComplexity      float64               `json:"average_sound_complexity"`
+}
+
+// corpusSoundStats tallies difficult sounds and combinations over analyzed twisters, counting
+// combinations the same way as the difficulty score (see comboOccurrences)
+func corpusSoundStats(twisters []TongueTwister) SoundStats {
+	stats := SoundStats{
+		Twisters:        len(twisters),
+		DifficultSounds: make(map[string]SoundCount),
+		DifficultCombos: make(map[string]SoundCount),
+	}
+	
+	totalSounds, totalCombos, totalComplexity := 0, 0, 0.0
+	for _, twister := range twisters {
+		text := strings.ToLower(twister.Text)
+		for _, sound := range difficultSounds {
+			if n := strings.Count(text, string(sound)); n > 0 {
+				count := stats.DifficultSounds[string(sound)]
+				count.Occurrences += n
+				count.Twisters++
+				stats.DifficultSounds[string(sound)] = count
+			}
+		}
+		for combo, n := range comboOccurrences(text) {
+			count := stats.DifficultCombos[combo]
+			count.Occurrences += n
+			count.Twisters++
+			stats.DifficultCombos[combo] = count
+		}
+		
+		totalSounds += twister.Stats.DifficultSounds
+		totalCombos += twister.Stats.DifficultCombos
+		totalComplexity += twister.Stats.SoundComplexityScore
+	}
+	
+	if len(twisters) > 0 {
+		n := float64(len(twisters))
+		stats.AverageDifficultSounds = float64(totalSounds) / n
+		stats.AverageDifficultCombos = float64(totalCombos) / n
+		stats.AverageComplexity = totalComplexity / n
+	}
+	return stats
+}
+
+// writeSoundStats writes the statistics as indented JSON to path, or to stdout for "-"
+func writeSoundStats(path string, stats SoundStats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sound statistics: %w", err)
+	}
+	
+	if path == "-" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// scriptOf classifies the text by the script of its letters. A script counts as dominant
+// when at least 80% of the letters belong to it; otherwise the text is mixed.
+func scriptOf(text string) string {
+	cyrillic, latin, letters := 0, 0, 0
+	for _, char := range text {
+		if !unicode.IsLetter(char) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Cyrillic, char) {
+			cyrillic++
+		} else if unicode.Is(unicode.Latin, char) {
+			latin++
+		}
+	}
+	
+	switch {
+	case letters == 0:
+		return ScriptEmpty
+	case float64(cyrillic) >= float64(letters)*0.8:
+		return ScriptCyrillic
+	case float64(latin) >= float64(letters)*0.8:
+		return ScriptLatin
+	default:
+		return ScriptMixed
+	}
+}
+
 // analyzeTwister calculates various statistics for a tongue twister and assigns a difficulty score
 func analyzeTwister(twister *TongueTwister) {
-	text := strings.ToLower(twister.Text)
+	// Start from scratch: entries loaded from -dump-analyzed or -export-by-sound output carry
+	// their old stats, and the counters below would add to them
+	twister.Stats = TwisterStats{}
 	
-	// Count words
-	words := strings.Fields(text)
-	twister.Stats.WordCount = len(words)
+	source := twister.Text
+	if expandDigits {
+		source = digitsToWords(source)
+	}
+	text := strings.ToLower(source)
+	
+	// Count words; hyphenated forms count once, dashes not at all
+	twister.Stats.WordCount = len(tokenizeWords(text))
 	
 	// Count letters and classify them
 	charMap := make(map[rune]int)
@@ -296,10 +1928,11 @@ func analyzeTwister(twister *TongueTwister) {
 			twister.Stats.CharCount++
 			charMap[char]++
 			
-			// Count vowels and consonants for Russian language
-			if isRussianVowel(char) {
+			// Count vowels and consonants for Russian language; ь and ъ are neither
+			vowel, consonant := classifyLetter(char)
+			if vowel {
 				twister.Stats.VowelCount++
-			} else if unicode.IsLetter(char) {
+			} else if consonant {
 				twister.Stats.ConsonantCount++
 			}
 			
@@ -309,23 +1942,315 @@ func analyzeTwister(twister *TongueTwister) {
 			}
 		}
 	}
-	
-	// Count unique and repeated characters
-	twister.Stats.UniqueChars = len(charMap)
-	for _, count := range charMap {
-		if count > 1 {
-			twister.Stats.RepeatChars += count - 1
+	
+	// Count unique and repeated characters
+	twister.Stats.UniqueChars = len(charMap)
+	for _, count := range charMap {
+		if count > 1 {
+			twister.Stats.RepeatChars += count - 1
+		}
+	}
+	
+	// Count difficult combinations
+	twister.Stats.DifficultCombos = countDifficultCombinations(text)
+	
+	// Calculate sound complexity score
+	twister.Stats.SoundComplexityScore = calculateSoundComplexity(text)
+	
+	// Longest cluster of consecutive consonants
+	twister.Stats.MaxConsonantRun = longestConsonantRun(text)
+	
+	// Whole words and roots said more than once
+	twister.Stats.RepeatedWords = repeatedWordCount(text)
+	twister.Stats.RepeatedRoots = repeatedRootApprox(text)
+	
+	// Average number of vowel/consonant alternations per word
+	if twister.Stats.WordCount > 0 {
+		twister.Stats.TransitionDensity = float64(transitionCount(text)) / float64(twister.Stats.WordCount)
+	}
+	
+	// Calculate a difficulty score based on the statistics
+	twister.Score = calculateDifficultyScore(twister.Stats)
+	
+	// Always derive the level from the fresh score, so a level loaded from an earlier dump never goes stale
+	twister.Level = twisterLevel(*twister)
+}
+
+// transitionCount counts switches between vowels and consonants inside words.
+// Words are split by tokenizeWords, so dashes separate words as they do for WordCount.
+// Transitions are not counted across word boundaries, and non-letters are ignored,
+// as are letters that classifyLetter counts as neither (ь, ъ and, with -semivowel skip, й).
+func transitionCount(text string) int {
+	count := 0
+	for _, word := range tokenizeWords(strings.ToLower(text)) {
+		started := false
+		prevVowel := false
+		for _, char := range word {
+			if !unicode.IsLetter(char) {
+				continue
+			}
+			vowel, consonant := classifyLetter(char)
+			if !vowel && !consonant {
+				continue
+			}
+			if started && vowel != prevVowel {
+				count++
+			}
+			started = true
+			prevVowel = vowel
+		}
+	}
+	return count
+}
+
+// longestConsonantRun returns the length of the longest run of consecutive consonant letters.
+// Runs are broken by vowels and non-letters. Letters that classifyLetter counts as neither
+// (ь and ъ, which carry no sound of their own, and й with -semivowel skip) neither extend nor
+// break a run; with -semivowel vowel й breaks it like a vowel.
+func longestConsonantRun(text string) int {
+	longest, current := 0, 0
+	for _, char := range strings.ToLower(text) {
+		vowel, consonant := classifyLetter(char)
+		switch {
+		case !unicode.IsLetter(char) || vowel:
+			current = 0
+		case !consonant:
+			continue
+		default:
+			current++
+			if current > longest {
+				longest = current
+			}
+		}
+	}
+	return longest
+}
+
+// expandDigits makes analyzeTwister read digits as Russian words (set by -expand-digits).
+// The displayed text is never changed.
+var expandDigits bool
+
+var (
+	russianUnits    = []string{"ноль", "один", "два", "три", "четыре", "пять", "шесть", "семь", "восемь", "девять"}
+	russianTeens    = []string{"десять", "одиннадцать", "двенадцать", "тринадцать", "четырнадцать", "пятнадцать", "шестнадцать", "семнадцать", "восемнадцать", "девятнадцать"}
+	russianTens     = []string{"", "", "двадцать", "тридцать", "сорок", "пятьдесят", "шестьдесят", "семьдесят", "восемьдесят", "девяносто"}
+	russianHundreds = []string{"", "сто", "двести", "триста", "четыреста", "пятьсот", "шестьсот", "семьсот", "восемьсот", "девятьсот"}
+)
+
+// digitsToWords replaces every run of digits in text with the Russian cardinal number,
+// e.g. "5 котят" becomes "пять котят" and "2024" becomes "две тысячи двадцать четыре".
+// Numbers of a million and above are read digit by digit.
+func digitsToWords(text string) string {
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if runes[i] < '0' || runes[i] > '9' {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && runes[j] >= '0' && runes[j] <= '9' {
+			j++
+		}
+		b.WriteString(numberToRussianWords(string(runes[i:j])))
+		i = j
+	}
+	return b.String()
+}
+
+// numberToRussianWords spells a string of ASCII digits in Russian
+func numberToRussianWords(digits string) string {
+	n, err := strconv.Atoi(digits)
+	if err != nil || n >= 1000000 {
+		words := make([]string, 0, len(digits))
+		for _, d := range digits {
+			words = append(words, russianUnits[d-'0'])
+		}
+		return strings.Join(words, " ")
+	}
+	if n == 0 {
+		return russianUnits[0]
+	}
+	
+	var words []string
+	if thousands := n / 1000; thousands > 0 {
+		words = append(words, belowThousandWords(thousands, true)...)
+		switch {
+		case thousands%100 >= 11 && thousands%100 <= 19:
+			words = append(words, "тысяч")
+		case thousands%10 == 1:
+			words = append(words, "тысяча")
+		case thousands%10 >= 2 && thousands%10 <= 4:
+			words = append(words, "тысячи")
+		default:
+			words = append(words, "тысяч")
+		}
+	}
+	words = append(words, belowThousandWords(n%1000, false)...)
+	return strings.Join(words, " ")
+}
+
+// belowThousandWords spells 1-999; feminine selects "одна"/"две" as used before "тысяча"
+func belowThousandWords(n int, feminine bool) []string {
+	var words []string
+	if n >= 100 {
+		words = append(words, russianHundreds[n/100])
+	}
+	rest := n % 100
+	switch {
+	case rest >= 10 && rest <= 19:
+		return append(words, russianTeens[rest-10])
+	case rest >= 20:
+		words = append(words, russianTens[rest/10])
+	}
+	switch unit := rest % 10; {
+	case unit == 0:
+	case feminine && unit == 1:
+		words = append(words, "одна")
+	case feminine && unit == 2:
+		words = append(words, "две")
+	default:
+		words = append(words, russianUnits[unit])
+	}
+	return words
+}
+
+// rootPrefixLength is how many leading letters approximate a word's root; shorter words are ignored
+const rootPrefixLength = 4
+
+// wordDashes separate words even when written without spaces around them
+var wordDashes = strings.NewReplacer("—", " ", "–", " ")
+
+// wordHyphens are the hyphen variants that join parts of one word; all become "-"
+var wordHyphens = strings.NewReplacer("\u2010", "-", "\u2011", "-")
+
+// tokenizeWords splits text into words, keeping hyphenated forms such as «еле-еле» together
+// regardless of spacing: a hyphen touching only one of two neighbouring words («еле- еле»,
+// «еле -еле») still joins them. Em and en dashes, and hyphens with spaces on both sides,
+// separate words and are not words themselves. Surrounding punctuation is removed.
+func tokenizeWords(text string) []string {
+	fields := strings.Fields(wordDashes.Replace(wordHyphens.Replace(text)))
+	
+	var words []string
+	for i := 0; i < len(fields); i++ {
+		word := fields[i]
+		for i+1 < len(fields) && joinedByHyphen(word, fields[i+1]) {
+			word += fields[i+1]
+			i++
+		}
+		word = strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// joinedByHyphen reports whether two neighbouring fields are parts of one hyphenated word:
+// the left one ends with a hyphen right after a letter, or the right one starts with a hyphen
+// right before a letter, and the other side is a word
+func joinedByHyphen(left, right string) bool {
+	l := []rune(left)
+	r := []rune(right)
+	if len(l) >= 2 && l[len(l)-1] == '-' && unicode.IsLetter(l[len(l)-2]) {
+		return unicode.IsLetter(r[0])
+	}
+	if len(r) >= 2 && r[0] == '-' && unicode.IsLetter(r[1]) {
+		return unicode.IsLetter(l[len(l)-1])
+	}
+	return false
+}
+
+// normalizedWords returns the words of the text in lower case with surrounding punctuation removed
+func normalizedWords(text string) []string {
+	words := tokenizeWords(strings.ToLower(text))
+	for i, word := range words {
+		words[i] = strings.ReplaceAll(word, "ё", "е")
+	}
+	return words
+}
+
+// repeatedWords returns the words that occur more than once, in order of first repetition
+func repeatedWords(text string) []string {
+	var repeated []string
+	counts := make(map[string]int)
+	for _, word := range normalizedWords(text) {
+		counts[word]++
+		if counts[word] == 2 {
+			repeated = append(repeated, word)
+		}
+	}
+	return repeated
+}
+
+// repeatedWordCount counts repetitions of whole words: every occurrence after the first.
+// Unlike RepeatChars this ignores letters repeated across different words.
+func repeatedWordCount(text string) int {
+	count := 0
+	seen := make(map[string]bool)
+	for _, word := range normalizedWords(text) {
+		if seen[word] {
+			count++
 		}
+		seen[word] = true
+	}
+	return count
+}
+
+// repeatedRootApprox counts words that share their first rootPrefixLength letters with an earlier,
+// different word, e.g. "говорит" after "скороговорка" does not match but "говорю" after "говорит" does.
+// Exact repeats are left to repeatedWordCount.
+func repeatedRootApprox(text string) int {
+	count := 0
+	wordsByRoot := make(map[string]map[string]bool)
+	for _, word := range normalizedWords(text) {
+		runes := []rune(word)
+		if len(runes) < rootPrefixLength {
+			continue
+		}
+		root := string(runes[:rootPrefixLength])
+		if wordsByRoot[root] == nil {
+			wordsByRoot[root] = make(map[string]bool)
+		}
+		if !wordsByRoot[root][word] && len(wordsByRoot[root]) > 0 {
+			count++
+		}
+		wordsByRoot[root][word] = true
+	}
+	return count
+}
+
+// Policies for counting the semivowel й (set by -semivowel)
+const (
+	SemivowelConsonant = "consonant" // й is a consonant, as before
+	SemivowelVowel     = "vowel"     // й is counted with the vowels
+	SemivowelSkip      = "skip"      // й is counted as neither
+)
+
+// semivowelPolicy decides how classifyLetter counts й
+var semivowelPolicy = SemivowelConsonant
+
+// classifyLetter reports whether a lower-case letter counts as a vowel or a consonant in the
+// consonant/vowel ratio. The signs ь and ъ are not sounds and count as neither;
+// й follows semivowelPolicy. Every other non-vowel letter is a consonant.
+func classifyLetter(char rune) (vowel, consonant bool) {
+	switch {
+	case isRussianVowel(char):
+		return true, false
+	case char == 'ь' || char == 'ъ':
+		return false, false
+	case char == 'й':
+		switch semivowelPolicy {
+		case SemivowelVowel:
+			return true, false
+		case SemivowelSkip:
+			return false, false
+		}
+		return false, true
+	default:
+		return false, true
 	}
-	
-	// Count difficult combinations
-	twister.Stats.DifficultCombos = countDifficultCombinations(text)
-	
-	// Calculate sound complexity score
-	twister.Stats.SoundComplexityScore = calculateSoundComplexity(text)
-	
-	// Calculate a difficulty score based on the statistics
-	twister.Score = calculateDifficultyScore(twister.Stats)
 }
 
 // isRussianVowel checks if a character is a Russian vowel
@@ -349,15 +2274,179 @@ func isRussianDifficultSound(char rune) bool {
 	return false
 }
 
-// countDifficultCombinations counts the number of difficult sound combinations in a text
+// countDifficultCombinations counts the number of difficult sound combinations in a text.
+// The text is scanned left to right taking the longest combination at each position, so
+// overlapping combinations such as "встр" and "стр" count a cluster only once.
 func countDifficultCombinations(text string) int {
 	count := 0
-	for _, combo := range difficultCombinations {
-		count += strings.Count(text, combo)
+	for _, n := range comboOccurrences(text) {
+		count += n
 	}
 	return count
 }
 
+// comboOccurrences returns how many times each difficult combination occurs in the text,
+// using the same longest-match scan as countDifficultCombinations
+func comboOccurrences(text string) map[string]int {
+	lower := []rune(strings.ToLower(text))
+	occurrences := make(map[string]int)
+	for i := 0; i < len(lower); {
+		if n := comboLengthAt(lower, i); n > 0 {
+			occurrences[string(lower[i:i+n])]++
+			i += n
+			continue
+		}
+		i++
+	}
+	return occurrences
+}
+
+// comboLengthAt returns the length in runes of the longest difficult combination starting at position i
+// of the lower-cased text, or 0 if none starts there
+func comboLengthAt(lower []rune, i int) int {
+	longest := 0
+	for _, combo := range difficultCombinations {
+		comboRunes := []rune(combo)
+		if len(comboRunes) <= longest || i+len(comboRunes) > len(lower) {
+			continue
+		}
+		if string(lower[i:i+len(comboRunes)]) == combo {
+			longest = len(comboRunes)
+		}
+	}
+	return longest
+}
+
+// highlightCombosInText wraps every difficult combination in the text in square brackets.
+// Overlapping combinations are resolved left to right with the longest match winning.
+func highlightCombosInText(text string) string {
+	original := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	// Lower-casing Cyrillic never changes the rune count, but guard against exotic input
+	if len(lower) != len(original) {
+		return text
+	}
+	
+	var b strings.Builder
+	for i := 0; i < len(original); {
+		if n := comboLengthAt(lower, i); n > 0 {
+			b.WriteString("[" + string(original[i:i+n]) + "]")
+			i += n
+			continue
+		}
+		b.WriteRune(original[i])
+		i++
+	}
+	return b.String()
+}
+
+// comboTransitionBonus is added to the weight of every letter inside a difficult combination
+const comboTransitionBonus = 0.3
+
+// charDifficultyWeights returns a weight from 0 to 1 for every rune of the text: the weight of
+// its sound group from soundProgressionGroups scaled to 0-1, raised for letters inside a
+// difficult combination where one hard sound runs into another. Non-letters get 0.
+func charDifficultyWeights(text string) []float64 {
+	lower := []rune(strings.ToLower(text))
+	weights := make([]float64, len(lower))
+	
+	maxWeight := 0.0
+	groupWeight := make(map[rune]float64)
+	for _, group := range soundProgressionGroups {
+		for _, sound := range group.Sounds {
+			groupWeight[sound] = group.Weight
+		}
+		if group.Weight > maxWeight {
+			maxWeight = group.Weight
+		}
+	}
+	if maxWeight == 0 {
+		return weights
+	}
+	
+	for i, char := range lower {
+		weights[i] = groupWeight[char] / maxWeight
+	}
+	for i := 0; i < len(lower); i++ {
+		for j := i; j < i+comboLengthAt(lower, i); j++ {
+			weights[j] = math.Min(1, weights[j]+comboTransitionBonus)
+		}
+	}
+	return weights
+}
+
+// renderHeatmap shades every rune of the text with ANSI intensity by its weight (0-1):
+// faint for easy sounds, plain, bold, and bold red for the hardest ones
+func renderHeatmap(text string, weights []float64) string {
+	var b strings.Builder
+	for i, char := range []rune(text) {
+		weight := 0.0
+		if i < len(weights) {
+			weight = weights[i]
+		}
+		switch {
+		case weight >= 0.9:
+			b.WriteString("\033[1;31m" + string(char) + "\033[0m")
+		case weight >= 0.6:
+			b.WriteString("\033[1m" + string(char) + "\033[0m")
+		case weight >= 0.3:
+			b.WriteRune(char)
+		default:
+			b.WriteString("\033[2m" + string(char) + "\033[0m")
+		}
+	}
+	return b.String()
+}
+
+// heatmapSample renders the whole text with one heatmap weight, for the legend
+func heatmapSample(text string, weight float64) string {
+	weights := make([]float64, len([]rune(text)))
+	for i := range weights {
+		weights[i] = weight
+	}
+	return renderHeatmap(text, weights)
+}
+
+// dominantSoundGroup returns the name of the soundProgressionGroups entry that contributes the most
+// to the text's sound complexity, i.e. the largest letter count times group weight.
+// Ties go to the harder group; false is returned for text without any grouped letters.
+func dominantSoundGroup(text string) (string, bool) {
+	counts := make(map[rune]int)
+	for _, char := range strings.ToLower(text) {
+		counts[char]++
+	}
+	
+	dominant := ""
+	best := 0.0
+	for _, group := range soundProgressionGroups {
+		contribution := 0.0
+		for _, sound := range group.Sounds {
+			contribution += float64(counts[sound]) * group.Weight
+		}
+		if contribution > 0 && contribution >= best {
+			dominant = group.Name
+			best = contribution
+		}
+	}
+	return dominant, dominant != ""
+}
+
+// printGroupCounts prints how many twisters each sound group dominates, in group order
+func printGroupCounts(twisters []TongueTwister) {
+	counts := make(map[string]int)
+	for _, twister := range twisters {
+		if group, ok := dominantSoundGroup(twister.Text); ok {
+			counts[group]++
+		}
+	}
+	
+	fmt.Println("Скороговорки по преобладающей группе звуков:")
+	for _, group := range soundProgressionGroups {
+		fmt.Printf("  %s: %d\n", group.Name, counts[group.Name])
+	}
+	fmt.Println()
+}
+
 // calculateSoundComplexity analyzes text for sound complexity based on progression groups
 func calculateSoundComplexity(text string) float64 {
 	text = strings.ToLower(text)
@@ -393,64 +2482,520 @@ func calculateSoundComplexity(text string) float64 {
 	return totalWeight / float64(soundCount)
 }
 
+// ScoreComponent is a single weighted term of the difficulty score
+type ScoreComponent struct {
+	Name         string
+	Value        float64
+	Weight       float64
+	Contribution float64
+}
+
+// ScoreBreakdown lists every term that makes up a difficulty score
+type ScoreBreakdown struct {
+	Components []ScoreComponent
+	Total      float64
+}
+
+// add appends a component and accumulates its contribution into the total
+func (b *ScoreBreakdown) add(name string, value, weight float64) {
+	contribution := value * weight
+	b.Components = append(b.Components, ScoreComponent{
+		Name:         name,
+		Value:        value,
+		Weight:       weight,
+		Contribution: contribution,
+	})
+	b.Total += contribution
+}
+
 // calculateDifficultyScore assigns a numeric difficulty score to a tongue twister
 func calculateDifficultyScore(stats TwisterStats) float64 {
+	return calculateScoreBreakdown(stats).Total
+}
+
+// ScoreWeights are the multipliers of the difficulty score components
+type ScoreWeights struct {
+	Words           float64
+	Letters         float64
+	ConsonantRatio  float64
+	Repeats         float64
+	DifficultSounds float64
+	DifficultCombos float64
+	SoundComplexity float64
+	Transitions     float64
+	RepeatedWords   float64
+	RepeatedRoots   float64
+}
+
+// Audiences accepted by -audience
+const (
+	AudienceAdult = "adult"
+	AudienceChild = "child"
+)
+
+// audienceWeights holds the score weights per audience. Children read long phrases more easily
+// than they pronounce consonant clusters, so for them length counts less and clusters count more.
+var audienceWeights = map[string]ScoreWeights{
+	AudienceAdult: {Words: 0.5, Letters: 0.1, ConsonantRatio: 2.0, Repeats: 0.3, DifficultSounds: 0.5, DifficultCombos: 1.0, SoundComplexity: 1.5, Transitions: 0.5, RepeatedWords: 0.3, RepeatedRoots: 0.2},
+	AudienceChild: {Words: 0.25, Letters: 0.05, ConsonantRatio: 2.0, Repeats: 0.3, DifficultSounds: 0.75, DifficultCombos: 2.0, SoundComplexity: 1.5, Transitions: 0.5, RepeatedWords: 0.3, RepeatedRoots: 0.2},
+}
+
+// scoreWeights is used by calculateScoreBreakdown; set from -audience
+var scoreWeights = audienceWeights[AudienceAdult]
+
+// dominantDifficultyFactor returns the name of the score component that contributes the
+// most to the difficulty, in lower case, or "" if nothing contributes
+func dominantDifficultyFactor(stats TwisterStats) string {
+	dominant, largest := "", 0.0
+	for _, component := range calculateScoreBreakdown(stats).Components {
+		if component.Contribution > largest {
+			dominant, largest = component.Name, component.Contribution
+		}
+	}
+	return strings.ToLower(dominant)
+}
+
+// calculateScoreBreakdown computes the difficulty score together with the contribution of each component
+func calculateScoreBreakdown(stats TwisterStats) ScoreBreakdown {
+	var breakdown ScoreBreakdown
+	w := scoreWeights
+	
 	// Base difficulty is proportional to length
-	score := float64(stats.WordCount) * 0.5
+	breakdown.add("Слова", float64(stats.WordCount), w.Words)
 	
 	// More characters increase difficulty
-	score += float64(stats.CharCount) * 0.1
+	breakdown.add("Буквы", float64(stats.CharCount), w.Letters)
 	
 	// Consonant to vowel ratio affects difficulty
 	consonantVowelRatio := 1.0
 	if stats.VowelCount > 0 {
 		consonantVowelRatio = float64(stats.ConsonantCount) / float64(stats.VowelCount)
 	}
-	score += consonantVowelRatio * 2.0
+	breakdown.add("Согласные/гласные", consonantVowelRatio, w.ConsonantRatio)
 	
 	// Repeated characters increase difficulty
-	score += float64(stats.RepeatChars) * 0.3
+	breakdown.add("Повторы букв", float64(stats.RepeatChars), w.Repeats)
 	
 	// Factor in difficult sounds and combinations
-	score += float64(stats.DifficultSounds) * 0.5
-	score += float64(stats.DifficultCombos) * 1.0
+	breakdown.add("Сложные звуки", float64(stats.DifficultSounds), w.DifficultSounds)
+	breakdown.add("Сложные сочетания", float64(stats.DifficultCombos), w.DifficultCombos)
 	
 	// Include sound complexity score
-	score += stats.SoundComplexityScore * 1.5
+	breakdown.add("Сложность звуков", stats.SoundComplexityScore, w.SoundComplexity)
 	
-	return score
+	// Rapid alternation between vowels and consonants
+	breakdown.add("Переходы гласная/согласная", stats.TransitionDensity, w.Transitions)
+	
+	// Repeating words and roots is easy to stumble over
+	breakdown.add("Повторы слов", float64(stats.RepeatedWords), w.RepeatedWords)
+	breakdown.add("Повторы корней", float64(stats.RepeatedRoots), w.RepeatedRoots)
+	
+	return breakdown
+}
+
+// explainTwisterScore prints each component of a twister's difficulty score
+func explainTwisterScore(twister TongueTwister) {
+	breakdown := calculateScoreBreakdown(twister.Stats)
+	
+	fmt.Printf("Скороговорка №%s:\n", twisterID(twister))
+	fmt.Println(twister.Text)
+	fmt.Println()
+	fmt.Println("Составляющие оценки сложности:")
+	for _, c := range breakdown.Components {
+		fmt.Printf("  %s: %.2f × %.1f = %.2f\n", c.Name, c.Value, c.Weight, c.Contribution)
+	}
+	fmt.Printf("Итого: %.2f (%s, сложнее %d%% скороговорок)\n", breakdown.Total, twisterLevel(twister), twister.Percentile)
+}
+
+// normalizedScore maps a score to a 0-100 percentile: the share of the corpus with a lower score.
+// The corpus must be sorted by Score in ascending order.
+func normalizedScore(score float64, corpus []TongueTwister) int {
+	if len(corpus) == 0 {
+		return 0
+	}
+	
+	// Number of twisters strictly easier than this score
+	below := sort.Search(len(corpus), func(i int) bool {
+		return corpus[i].Score >= score
+	})
+	
+	return int(math.Round(float64(below) / float64(len(corpus)) * 100))
+}
+
+// DifficultyThresholds are the exclusive upper score bounds of the easy, medium and hard levels;
+// scores at or above Hard are expert
+type DifficultyThresholds struct {
+	Easy   float64
+	Medium float64
+	Hard   float64
+}
+
+// difficultyThresholds is used by getDifficultyLevel; set from -easy-below, -medium-below and -hard-below
+var difficultyThresholds = DifficultyThresholds{Easy: 10, Medium: 20, Hard: 30}
+
+// valid reports whether the thresholds are positive and strictly increasing
+func (t DifficultyThresholds) valid() bool {
+	return t.Easy > 0 && t.Easy < t.Medium && t.Medium < t.Hard
+}
+
+// flags returns the command line flags that reproduce these thresholds
+func (t DifficultyThresholds) flags() string {
+	return fmt.Sprintf("-easy-below %g -medium-below %g -hard-below %g", t.Easy, t.Medium, t.Hard)
 }
 
 // getDifficultyLevel returns a human-readable difficulty level based on the score
 func getDifficultyLevel(score float64) string {
-	if score < 10 {
+	if score < difficultyThresholds.Easy {
 		return Easy
-	} else if score < 20 {
+	} else if score < difficultyThresholds.Medium {
 		return Medium
-	} else if score < 30 {
+	} else if score < difficultyThresholds.Hard {
 		return Hard
 	} else {
 		return Expert
 	}
 }
 
-// filterTwistersByDifficulty returns tongue twisters of a specific difficulty level
-func filterTwistersByDifficulty(twisters []TongueTwister, level string) []TongueTwister {
+// DifficultyClassifier assigns one of the difficulty levels (Easy, Medium, Hard, Expert) to an analyzed twister
+type DifficultyClassifier interface {
+	Level(twister TongueTwister) string
+}
+
+// ScoreClassifier buckets twisters by their composite difficulty score, using difficultyThresholds
+type ScoreClassifier struct{}
+
+// Level returns the level of the twister's score
+func (ScoreClassifier) Level(twister TongueTwister) string {
+	return getDifficultyLevel(twister.Score)
+}
+
+// SyllableClassifier buckets twisters by their total number of syllables. The cutoffs are
+// the exclusive upper bounds of the easy, medium and hard levels; longer twisters are expert.
+type SyllableClassifier struct {
+	Easy   int
+	Medium int
+	Hard   int
+}
+
+// Level returns the level of the twister's syllable count
+func (c SyllableClassifier) Level(twister TongueTwister) string {
+	syllables := countSyllables(twister.Text)
+	switch {
+	case syllables < c.Easy:
+		return Easy
+	case syllables < c.Medium:
+		return Medium
+	case syllables < c.Hard:
+		return Hard
+	default:
+		return Expert
+	}
+}
+
+// Classifier names accepted by -classify
+const (
+	ClassifyScore     = "score"
+	ClassifySyllables = "syllables"
+)
+
+// difficultyClassifier decides every twister's level; set from -classify
+var difficultyClassifier DifficultyClassifier = ScoreClassifier{}
+
+// newClassifier returns the classifier named by -classify. cutoffs holds the three
+// comma-separated syllable cutoffs and is only used by the syllable classifier.
+func newClassifier(name, cutoffs string) (DifficultyClassifier, error) {
+	switch strings.ToLower(name) {
+	case ClassifyScore:
+		return ScoreClassifier{}, nil
+	case ClassifySyllables:
+		parts := strings.Split(cutoffs, ",")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("expected three comma-separated syllable cutoffs, got %q", cutoffs)
+		}
+		var values [3]int
+		for i, part := range parts {
+			value, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("invalid syllable cutoff %q", part)
+			}
+			values[i] = value
+		}
+		if values[0] <= 0 || values[0] >= values[1] || values[1] >= values[2] {
+			return nil, fmt.Errorf("syllable cutoffs must be positive and increasing, got %q", cutoffs)
+		}
+		return SyllableClassifier{Easy: values[0], Medium: values[1], Hard: values[2]}, nil
+	}
+	return nil, fmt.Errorf("unknown classifier %q: expected %s or %s", name, ClassifyScore, ClassifySyllables)
+}
+
+// twisterLevel returns the twister's difficulty level according to the selected classifier
+func twisterLevel(twister TongueTwister) string {
+	return difficultyClassifier.Level(twister)
+}
+
+// levelIndex returns the position of a level in the easy-to-expert order
+func levelIndex(level string) int {
+	levels := []string{Easy, Medium, Hard, Expert}
+	for i, name := range levels {
+		if name == level {
+			return i
+		}
+	}
+	return len(levels)
+}
+
+// twisterIDHashLength is the number of hex digits of the text hash kept in generated IDs
+const twisterIDHashLength = 10
+
+// twisterID returns a stable key for the twister: its Number, or for entries without one
+// "t" followed by a short hash of the normalized text, so the same text gets the same ID in every run.
+// Profiles, exclusions, deduplication, reports and transcripts all key twisters by this ID.
+func twisterID(t TongueTwister) string {
+	if number := strings.TrimSpace(t.Number); number != "" {
+		return number
+	}
+	sum := sha1.Sum([]byte(normalizeForComparison(t.Text)))
+	return "t" + hex.EncodeToString(sum[:])[:twisterIDHashLength]
+}
+
+// findTwisterByNumber returns the twister with the given ID (see twisterID)
+func findTwisterByNumber(twisters []TongueTwister, number string) (TongueTwister, bool) {
+	for _, twister := range twisters {
+		if twisterID(twister) == number {
+			return twister, true
+		}
+	}
+	return TongueTwister{}, false
+}
+
+// difficultyLevelByName maps a difficulty name as used on the command line (easy, medium, hard, expert) to its level
+func difficultyLevelByName(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "easy":
+		return Easy, true
+	case "medium":
+		return Medium, true
+	case "hard":
+		return Hard, true
+	case "expert":
+		return Expert, true
+	}
+	return "", false
+}
+
+// filterTwistersByDifficulty returns tongue twisters of a specific difficulty level
+func filterTwistersByDifficulty(twisters []TongueTwister, level string) []TongueTwister {
+	var filtered []TongueTwister
+	for _, twister := range twisters {
+		if twisterLevel(twister) == level {
+			filtered = append(filtered, twister)
+		}
+	}
+	return filtered
+}
+
+// minConfidentBucket is the smallest number of twisters in a level for its boundaries to be trusted
+const minConfidentBucket = 10
+
+// bucketConfidence returns a warning note for every difficulty level with too few twisters
+// to judge whether its boundaries suit the corpus. Levels with enough twisters are absent.
+func bucketConfidence(counts map[string]int) map[string]string {
+	notes := make(map[string]string)
+	for level, count := range counts {
+		if count < minConfidentBucket {
+			notes[level] = fmt.Sprintf("мало данных: меньше %d, границы уровня ненадёжны", minConfidentBucket)
+		}
+	}
+	return notes
+}
+
+// printPreviewSet lists the selected twisters in training order with their main stats
+func printPreviewSet(twisters []TongueTwister) {
+	fmt.Printf("Набор для тренировки: %d скороговорок\n", len(twisters))
+	for i, twister := range twisters {
+		fmt.Println(separator())
+		fmt.Printf("%d. №%s — %s (%.1f, сложнее %d%% скороговорок)\n",
+			i+1, twisterID(twister), twisterLevel(twister), twister.Score, twister.Percentile)
+		fmt.Printf("   %d слов, %d букв, сложных звуков: %d, сложных сочетаний: %d\n",
+			twister.Stats.WordCount, twister.Stats.CharCount, twister.Stats.DifficultSounds, twister.Stats.DifficultCombos)
+		fmt.Println(twister.Text)
+	}
+}
+
+// printLevelCounts prints how many twisters fall into each difficulty level
+func printLevelCounts(twisters []TongueTwister) {
+	for _, level := range []string{Easy, Medium, Hard, Expert} {
+		fmt.Printf("  %s: %d\n", level, len(filterTwistersByDifficulty(twisters, level)))
+	}
+}
+
+// runThresholdTuner lets the user try new level thresholds and see the resulting bucket counts
+// until an empty line is entered, then prints the flags that reproduce the last valid choice
+func runThresholdTuner(twisters []TongueTwister) {
+	fmt.Printf("Скороговорок в корпусе: %d\n", len(twisters))
+	fmt.Printf("Текущие границы: %s\n", difficultyThresholds.flags())
+	printLevelCounts(twisters)
+	
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("\nВведите три границы (легкая средняя сложная) или пустую строку для завершения: ")
+		if !scanner.Scan() {
+			fmt.Println()
+			break
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			break
+		}
+		if len(fields) != 3 {
+			fmt.Println("Нужно ровно три числа, например: 12 22 35")
+			continue
+		}
+		
+		var values [3]float64
+		ok := true
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				fmt.Printf("Не число: %s\n", field)
+				ok = false
+				break
+			}
+			values[i] = value
+		}
+		if !ok {
+			continue
+		}
+		
+		candidate := DifficultyThresholds{Easy: values[0], Medium: values[1], Hard: values[2]}
+		if !candidate.valid() {
+			fmt.Println("Границы должны быть положительными и возрастать")
+			continue
+		}
+		difficultyThresholds = candidate
+		printLevelCounts(twisters)
+	}
+	
+	fmt.Printf("Выбранные границы: %s\n", difficultyThresholds.flags())
+}
+
+// shorterHalf returns the twisters whose word count does not exceed the median of the corpus
+func shorterHalf(twisters []TongueTwister) []TongueTwister {
+	if len(twisters) == 0 {
+		return twisters
+	}
+	counts := make([]int, len(twisters))
+	for i, twister := range twisters {
+		counts[i] = twister.Stats.WordCount
+	}
+	sort.Ints(counts)
+	median := counts[(len(counts)-1)/2]
+	
+	var filtered []TongueTwister
+	for _, twister := range twisters {
+		if twister.Stats.WordCount <= median {
+			filtered = append(filtered, twister)
+		}
+	}
+	return filtered
+}
+
+// loadExcludedNumbers reads a JSON array of twister numbers, e.g. ["12", "345"].
+// A missing file means nothing is excluded.
+func loadExcludedNumbers(path string) (map[string]bool, error) {
+	excluded := make(map[string]bool)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return excluded, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	
+	var numbers []string
+	if err := json.Unmarshal(data, &numbers); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, number := range numbers {
+		excluded[strings.TrimSpace(number)] = true
+	}
+	return excluded, nil
+}
+
+// excludeTwisters returns tongue twisters whose number is not in excluded
+func excludeTwisters(twisters []TongueTwister, excluded map[string]bool) []TongueTwister {
+	var kept []TongueTwister
+	for _, twister := range twisters {
+		if !excluded[twisterID(twister)] {
+			kept = append(kept, twister)
+		}
+	}
+	return kept
+}
+
+// filterTwistersByRegex returns tongue twisters whose text matches the pattern
+func filterTwistersByRegex(twisters []TongueTwister, pattern *regexp.Regexp) []TongueTwister {
 	var filtered []TongueTwister
 	for _, twister := range twisters {
-		if getDifficultyLevel(twister.Score) == level {
+		if pattern.MatchString(twister.Text) {
 			filtered = append(filtered, twister)
 		}
 	}
 	return filtered
 }
 
+// filterTwistersByWordCount returns tongue twisters whose word count lies within [minWords, maxWords].
+// A zero bound means no limit on that side.
+func filterTwistersByWordCount(twisters []TongueTwister, minWords, maxWords int) []TongueTwister {
+	var filtered []TongueTwister
+	for _, twister := range twisters {
+		if minWords > 0 && twister.Stats.WordCount < minWords {
+			continue
+		}
+		if maxWords > 0 && twister.Stats.WordCount > maxWords {
+			continue
+		}
+		filtered = append(filtered, twister)
+	}
+	return filtered
+}
+
+// sortedNumbers returns the twister IDs (numbers where present) in ascending numeric order so that listings are stable between runs
+func sortedNumbers(twisters []TongueTwister) []string {
+	numbers := make([]string, 0, len(twisters))
+	for _, twister := range twisters {
+		numbers = append(numbers, twisterID(twister))
+	}
+	
+	// Numeric IDs come first in numeric order, then the rest in string order, so the
+	// comparison stays transitive for mixed IDs such as "9", "100" and "12a"
+	sort.SliceStable(numbers, func(i, j int) bool {
+		a, errA := strconv.Atoi(numbers[i])
+		b, errB := strconv.Atoi(numbers[j])
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		default:
+			return numbers[i] < numbers[j]
+		}
+	})
+	
+	return numbers
+}
+
 // selectRandomTwisters selects n random tongue twisters from the given slice
 func selectRandomTwisters(twisters []TongueTwister, n int) []TongueTwister {
 	if n >= len(twisters) {
 		return twisters
 	}
 	
+	// With -coverage twisters carrying rare sounds are more likely to be picked
+	if coverageFreqs != nil {
+		return selectByRarity(twisters, n, coverageFreqs)
+	}
+	
 	// Create a copy of the slice to avoid modifying the original
 	shuffled := make([]TongueTwister, len(twisters))
 	copy(shuffled, twisters)
@@ -464,58 +3009,319 @@ func selectRandomTwisters(twisters []TongueTwister, n int) []TongueTwister {
 	return shuffled[:n]
 }
 
+// Sampler picks twisters at random without repeating any until every candidate has been
+// shown in this session; then the candidates become available again. A nil Sampler picks
+// independently every time.
+type Sampler struct {
+	shown map[string]bool // IDs (twisterID) shown since the candidates were last exhausted
+}
+
+// sessionSampler is set by -no-repeat and shared by all selections within a session
+var sessionSampler *Sampler
+
+// NewSampler creates a sampler that has shown nothing yet
+func NewSampler() *Sampler {
+	return &Sampler{shown: make(map[string]bool)}
+}
+
+// Mark records twisters as shown, e.g. the initial training set
+func (s *Sampler) Mark(twisters ...TongueTwister) {
+	if s == nil {
+		return
+	}
+	for _, twister := range twisters {
+		s.shown[twisterID(twister)] = true
+	}
+}
+
+// Pick returns a random candidate that hasn't been shown yet and marks it as shown.
+// ok is false when there are no candidates.
+func (s *Sampler) Pick(candidates []TongueTwister) (twister TongueTwister, ok bool) {
+	picked := s.Sample(candidates, 1)
+	if len(picked) == 0 {
+		return TongueTwister{}, false
+	}
+	return picked[0], true
+}
+
+// Sample returns n random candidates, preferring ones not shown yet. When fewer than n are
+// left, the rest are drawn after the candidates are made available again.
+func (s *Sampler) Sample(candidates []TongueTwister, n int) []TongueTwister {
+	if s == nil {
+		return selectRandomTwisters(candidates, n)
+	}
+	
+	var fresh []TongueTwister
+	for _, twister := range candidates {
+		if !s.shown[twisterID(twister)] {
+			fresh = append(fresh, twister)
+		}
+	}
+	picked := selectRandomTwisters(fresh, n)
+	
+	// Every candidate has been shown: start over, without repeating what was just picked
+	if len(picked) < n {
+		taken := make(map[string]bool, len(picked))
+		for _, twister := range picked {
+			taken[twisterID(twister)] = true
+		}
+		var rest []TongueTwister
+		for _, twister := range candidates {
+			delete(s.shown, twisterID(twister))
+			if !taken[twisterID(twister)] {
+				rest = append(rest, twister)
+			}
+		}
+		picked = append(picked, selectRandomTwisters(rest, n-len(picked))...)
+	}
+	s.Mark(picked...)
+	return picked
+}
+
+// coverageFreqs holds how often each letter occurs in the corpus; set by -coverage
+var coverageFreqs map[rune]int
+
+// soundFrequencies counts how often each letter occurs across all twisters
+func soundFrequencies(twisters []TongueTwister) map[rune]int {
+	freqs := make(map[rune]int)
+	for _, twister := range twisters {
+		for _, char := range strings.ToLower(twister.Text) {
+			if unicode.IsLetter(char) {
+				freqs[char]++
+			}
+		}
+	}
+	return freqs
+}
+
+// soundRarityScore rates the rarest sound in the text: ln(most common letter count / its count).
+// A text made only of the most common letters scores 0; a single rare letter raises the score.
+func soundRarityScore(text string, freqs map[rune]int) float64 {
+	maxFreq := 0
+	for _, freq := range freqs {
+		if freq > maxFreq {
+			maxFreq = freq
+		}
+	}
+	
+	score := 0.0
+	for _, char := range strings.ToLower(text) {
+		freq := freqs[char]
+		if freq == 0 {
+			continue
+		}
+		if rarity := math.Log(float64(maxFreq) / float64(freq)); rarity > score {
+			score = rarity
+		}
+	}
+	return score
+}
+
+// selectByRarity draws n twisters without replacement, weighted by 1 + soundRarityScore
+func selectByRarity(twisters []TongueTwister, n int, freqs map[rune]int) []TongueTwister {
+	candidates := make([]TongueTwister, len(twisters))
+	copy(candidates, twisters)
+	weights := make([]float64, len(candidates))
+	for i, twister := range candidates {
+		weights[i] = 1 + soundRarityScore(twister.Text, freqs)
+	}
+	
+	result := make([]TongueTwister, 0, n)
+	for len(result) < n && len(candidates) > 0 {
+		totalWeight := 0.0
+		for _, weight := range weights {
+			totalWeight += weight
+		}
+		
+		pick := rand.Float64() * totalWeight
+		index := len(candidates) - 1
+		for i, weight := range weights {
+			pick -= weight
+			if pick <= 0 {
+				index = i
+				break
+			}
+		}
+		
+		result = append(result, candidates[index])
+		candidates = append(candidates[:index], candidates[index+1:]...)
+		weights = append(weights[:index], weights[index+1:]...)
+	}
+	
+	return result
+}
+
+// selectNearScore selects n tongue twisters whose scores are close to the target.
+// The 3n nearest twisters form the candidate window; within it twisters are drawn
+// at random without replacement, weighted by 1/(1+distance) so closer scores are preferred.
+func selectNearScore(twisters []TongueTwister, target float64, n int) []TongueTwister {
+	if n <= 0 || len(twisters) == 0 {
+		return nil
+	}
+	
+	// Order a copy by distance from the target
+	candidates := make([]TongueTwister, len(twisters))
+	copy(candidates, twisters)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return math.Abs(candidates[i].Score-target) < math.Abs(candidates[j].Score-target)
+	})
+	if window := n * 3; window < len(candidates) {
+		candidates = candidates[:window]
+	}
+	
+	result := make([]TongueTwister, 0, n)
+	for len(result) < n && len(candidates) > 0 {
+		totalWeight := 0.0
+		for _, twister := range candidates {
+			totalWeight += 1 / (1 + math.Abs(twister.Score-target))
+		}
+		
+		pick := rand.Float64() * totalWeight
+		index := len(candidates) - 1
+		for i, twister := range candidates {
+			pick -= 1 / (1 + math.Abs(twister.Score-target))
+			if pick <= 0 {
+				index = i
+				break
+			}
+		}
+		
+		result = append(result, candidates[index])
+		candidates = append(candidates[:index], candidates[index+1:]...)
+	}
+	
+	return result
+}
+
+// runCooldown ends a session with a few easy twisters, read at a relaxed pace
+func runCooldown(twisters []TongueTwister) {
+	if len(twisters) == 0 {
+		return
+	}
+	
+	fmt.Println()
+	fmt.Println("=== Заминка ===")
+	fmt.Println("Несколько лёгких скороговорок, чтобы закончить на уверенной ноте. Читайте спокойно, без спешки.")
+	fmt.Println()
+	
+	for i, twister := range twisters {
+		fmt.Printf("Заминка %d из %d:\n", i+1, len(twisters))
+		fmt.Println(twister.Text)
+		fmt.Println()
+		transcript.Record("shown", twisterID(twister), "cooldown")
+		
+		fmt.Println("Нажмите Enter для перехода к следующей скороговорке...")
+		waitForEnter()
+		fmt.Println(separator())
+	}
+}
+
 // runStandardTrainingSession conducts an interactive training session with the selected tongue twisters
-func runStandardTrainingSession(twisters []TongueTwister) {
+func runStandardTrainingSession(twisters []TongueTwister, spotlight, heatmap bool, autoAdvance int) {
 	fmt.Println("=== Начинаем стандартную тренировку ===")
 	fmt.Printf("Выбрано %d скороговорок для практики.\n\n", len(twisters))
+	if heatmap {
+		fmt.Printf("Тепловая карта: %s, %s, %s, %s\n\n",
+			heatmapSample("бледные", 0), heatmapSample("обычные", 0.5),
+			heatmapSample("жирные", 0.7), heatmapSample("красные — самые трудные звуки", 1))
+	}
 	
 	for i, twister := range twisters {
 		fmt.Printf("Скороговорка %d из %d:\n", i+1, len(twisters))
-		fmt.Printf("Сложность: %s (%.1f)\n", getDifficultyLevel(twister.Score), twister.Score)
+		fmt.Printf("Сложность: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
 		fmt.Printf("Статистика: %d слов, %d букв (%d гласных, %d согласных)\n", 
 			twister.Stats.WordCount, twister.Stats.CharCount, 
 			twister.Stats.VowelCount, twister.Stats.ConsonantCount)
 		fmt.Println()
-		fmt.Println(twister.Text)
+		if heatmap {
+			fmt.Println(renderHeatmap(twister.Text, charDifficultyWeights(twister.Text)))
+		} else if spotlight {
+			fmt.Println(highlightCombosInText(twister.Text))
+		} else {
+			fmt.Println(twister.Text)
+		}
 		fmt.Println()
+		transcript.Record("shown", twisterID(twister), "")
 		
-		fmt.Println("Нажмите Enter для перехода к следующей скороговорке...")
-		bufio.NewReader(os.Stdin).ReadBytes('\n')
-		fmt.Println(strings.Repeat("-", 60))
+		if autoAdvance > 0 {
+			fmt.Printf("Переход к следующей скороговорке через %d секунд (Enter — перейти сразу)...\n", autoAdvance)
+			waitForAutoAdvance(autoAdvance)
+		} else {
+			fmt.Println("Нажмите Enter для перехода к следующей скороговорке...")
+			waitForEnter()
+		}
+		transcript.Record("finished", twisterID(twister), "")
+		fmt.Println(separator())
 	}
 	
 	fmt.Println("=== Тренировка завершена ===")
 }
 
+// waitForAutoAdvance waits until the given number of seconds has passed or Enter is pressed
+func waitForAutoAdvance(seconds int) {
+	// An Enter pressed just after the previous timeout must not skip this twister
+	drainPendingInput()
+	lines := stdinLines()
+	remaining := seconds
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			remaining--
+			if remaining <= 0 {
+				fmt.Println("\rВремя вышло, следующая скороговорка!      ")
+				drainPendingInput()
+				return
+			} else if remaining <= 3 {
+				fmt.Printf("\rОсталось %d секунд...   ", remaining)
+			}
+		case _, ok := <-lines:
+			if !ok {
+				// Input is closed, so only the timer can move on
+				lines = nil
+				continue
+			}
+			return
+		}
+	}
+}
+
 // runTimedTrainingSession conducts a timed training session with the selected tongue twisters
-func runTimedTrainingSession(twisters []TongueTwister, secondsPerTwister int) {
+func runTimedTrainingSession(twisters []TongueTwister, secondsPerTwister int, profile *UserPerformance) {
 	fmt.Println("=== Начинаем тренировку на время ===")
 	fmt.Printf("Выбрано %d скороговорок для практики. На каждую скороговорку %d секунд.\n\n", len(twisters), secondsPerTwister)
 	
 	for i, twister := range twisters {
 		fmt.Printf("Скороговорка %d из %d:\n", i+1, len(twisters))
-		fmt.Printf("Сложность: %s (%.1f)\n", getDifficultyLevel(twister.Score), twister.Score)
+		fmt.Printf("Сложность: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
 		fmt.Printf("Статистика: %d слов, %d букв (%d гласных, %d согласных)\n", 
 			twister.Stats.WordCount, twister.Stats.CharCount, 
 			twister.Stats.VowelCount, twister.Stats.ConsonantCount)
 		fmt.Println()
 		fmt.Println(twister.Text)
 		fmt.Println()
+		transcript.Record("shown", twisterID(twister), "")
 		
 		fmt.Printf("Время на практику: %d секунд. Нажмите Enter, когда будете готовы начать...\n", secondsPerTwister)
-		bufio.NewReader(os.Stdin).ReadBytes('\n')
+		waitForEnter()
+		transcript.Record("started", twisterID(twister), "")
 		
 		// Start timer
 		fmt.Println("Время пошло! Повторяйте скороговорку...")
+		start := time.Now()
 		
-		// Create a channel for early completion
-		done := make(chan bool)
-		
-		// Start a goroutine to listen for user input
-		go func() {
-			bufio.NewReader(os.Stdin).ReadBytes('\n')
-			done <- true
-		}()
+		// Enter ends the attempt early; it comes through the shared input reader, so a reading
+		// still pending when the time runs out cannot swallow the answer to the next prompt.
+		// In non-interactive mode the attempt ends after the usual pause.
+		var lines <-chan string
+		var pause <-chan time.Time
+		if nonInteractive.Enabled {
+			pause = time.After(nonInteractive.Delay)
+		} else {
+			lines = stdinLines()
+		}
 		
 		// Timer loop
 		remaining := secondsPerTwister
@@ -531,20 +3337,59 @@ func runTimedTrainingSession(twisters []TongueTwister, secondsPerTwister int) {
 				} else if remaining <= 5 {
 					fmt.Printf("\rОсталось %d секунд...   ", remaining)
 				}
-			case <-done:
+			case _, ok := <-lines:
+				if !ok {
+					// Input is closed, so only the timer can end the attempt
+					lines = nil
+					continue
+				}
+				timerDone = true
+				fmt.Println("\rЗавершено раньше времени!                ")
+			case <-pause:
 				timerDone = true
 				fmt.Println("\rЗавершено раньше времени!                ")
 			}
 		}
 		
 		ticker.Stop()
+		if remaining <= 0 {
+			// An Enter pressed as the time ran out must not answer the question below
+			drainPendingInput()
+		}
+		elapsed := time.Since(start)
+		transcript.Record("finished", twisterID(twister), "")
 		fmt.Println("\nВремя истекло!")
-		fmt.Println(strings.Repeat("-", 60))
+		
+		// The pace comes from how many times the twister was read in the elapsed time
+		fmt.Print("Сколько раз вы прочитали скороговорку? (Enter — пропустить): ")
+		if readings, err := strconv.Atoi(readChoice()); err == nil && readings > 0 {
+			wpm := wordsPerMinute(twister.Stats.WordCount, readings, elapsed)
+			transcript.Record("wpm", twisterID(twister), strconv.FormatFloat(wpm, 'f', 1, 64))
+			fmt.Printf("Темп: %.0f слов в минуту\n", wpm)
+			if best, ok := profile.BestWPM[twisterID(twister)]; !ok || wpm > best {
+				if ok {
+					fmt.Printf("Новый рекорд скорости! Прежний лучший темп: %.0f слов в минуту\n", best)
+				}
+				profile.BestWPM[twisterID(twister)] = wpm
+			} else {
+				fmt.Printf("Ваш рекорд для этой скороговорки: %.0f слов в минуту\n", best)
+			}
+		}
+		fmt.Println(separator())
 	}
 	
 	fmt.Println("=== Тренировка завершена ===")
 }
 
+// wordsPerMinute returns the reading pace for a twister of wordCount words read the given
+// number of times within elapsed
+func wordsPerMinute(wordCount, readings int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(wordCount*readings) / elapsed.Minutes()
+}
+
 // runRepeatTrainingSession conducts a training session with repeated practice of each tongue twister
 func runRepeatTrainingSession(twisters []TongueTwister, repetitions int) {
 	fmt.Println("=== Начинаем тренировку с повторениями ===")
@@ -553,63 +3398,487 @@ func runRepeatTrainingSession(twisters []TongueTwister, repetitions int) {
 	
 	for i, twister := range twisters {
 		fmt.Printf("Скороговорка %d из %d:\n", i+1, len(twisters))
-		fmt.Printf("Сложность: %s (%.1f)\n", getDifficultyLevel(twister.Score), twister.Score)
+		fmt.Printf("Сложность: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
 		fmt.Printf("Статистика: %d слов, %d букв (%d гласных, %d согласных)\n", 
 			twister.Stats.WordCount, twister.Stats.CharCount, 
 			twister.Stats.VowelCount, twister.Stats.ConsonantCount)
 		fmt.Println()
 		fmt.Println(twister.Text)
 		fmt.Println()
+		transcript.Record("shown", twisterID(twister), "")
 		
 		fmt.Println("Нажмите Enter, когда будете готовы начать повторения...")
-		bufio.NewReader(os.Stdin).ReadBytes('\n')
+		waitForEnter()
+		transcript.Record("started", twisterID(twister), "")
 		
 		for rep := 1; rep <= repetitions; rep++ {
 			fmt.Printf("\rПовторение %d из %d. Нажмите Enter после прочтения...", rep, repetitions)
-			bufio.NewReader(os.Stdin).ReadBytes('\n')
+			waitForEnter()
+			transcript.Record("repetition", twisterID(twister), strconv.Itoa(rep))
 		}
+		transcript.Record("finished", twisterID(twister), "")
 		
 		fmt.Println("\nВы успешно повторили эту скороговорку!")
-		fmt.Println(strings.Repeat("-", 60))
+		fmt.Println(separator())
 	}
 	
 	fmt.Println("=== Тренировка завершена ===")
 }
 
-// runChallengeTrainingSession conducts a challenging training session with increasing speed
-func runChallengeTrainingSession(twisters []TongueTwister) {
-	fmt.Println("=== Начинаем тренировку-вызов ===")
-	fmt.Printf("Выбрано %d скороговорок для практики. Повторяйте каждую с увеличением скорости.\n\n", len(twisters))
+// runCumulativeTrainingSession builds each phrase up one word at a time, waiting for Enter
+// between steps, so the twister is memorized piece by piece
+func runCumulativeTrainingSession(twisters []TongueTwister) {
+	fmt.Println("=== Начинаем накопительную тренировку ===")
+	fmt.Printf("Выбрано %d скороговорок для практики. Фраза растёт по одному слову: повторяйте каждый шаг вслух.\n\n", len(twisters))
 	
-	speeds := []string{"Медленно", "Средне", "Быстро", "Очень быстро"}
+	for i, twister := range twisters {
+		fmt.Printf("Скороговорка %d из %d:\n", i+1, len(twisters))
+		fmt.Printf("Сложность: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
+		fmt.Println()
+		
+		transcript.Record("shown", twisterID(twister), "")
+		reveals := cumulativeReveals(twister.Text)
+		for step, reveal := range reveals {
+			fmt.Printf("Шаг %d из %d: %s\n", step+1, len(reveals), reveal)
+			waitForEnter()
+		}
+		
+		fmt.Println("Вся фраза целиком:")
+		fmt.Println(twister.Text)
+		fmt.Println("\nПрочитайте её без подсказок и нажмите Enter...")
+		waitForEnter()
+		transcript.Record("finished", twisterID(twister), "")
+		fmt.Println(separator())
+	}
+	
+	fmt.Println("=== Тренировка завершена ===")
+}
+
+// cumulativeReveals returns the phrase growing one word at a time: "Шла", "Шла Саша", ...
+// Words keep their punctuation, and the last step is the full phrase.
+func cumulativeReveals(text string) []string {
+	words := strings.Fields(text)
+	reveals := make([]string, len(words))
+	for i := range words {
+		reveals[i] = strings.Join(words[:i+1], " ")
+	}
+	return reveals
+}
+
+// similarCorpusLimit caps how many twisters similar mode compares pairwise. Finding
+// neighbors costs O(n²) edit distances, so larger corpora are randomly sampled down.
+const similarCorpusLimit = 400
+
+// similarNeighbors is how many nearest neighbors are kept for each twister in similar mode
+const similarNeighbors = 10
+
+// runSimilarTrainingSession presents twisters that differ only slightly back to back,
+// starting from start and each time moving on to the closest twister not yet shown
+func runSimilarTrainingSession(corpus []TongueTwister, start TongueTwister, count int) {
+	fmt.Println("=== Начинаем тренировку на похожих скороговорках ===")
+	
+	// Sample the corpus down to the limit, always keeping the starting twister first
+	pool := []TongueTwister{start}
+	for _, i := range rand.Perm(len(corpus)) {
+		if len(pool) >= similarCorpusLimit {
+			break
+		}
+		if twisterID(corpus[i]) != twisterID(start) {
+			pool = append(pool, corpus[i])
+		}
+	}
+	if len(corpus) > similarCorpusLimit {
+		fmt.Printf("Для поиска похожих взято %d случайных скороговорок из %d.\n", len(pool), len(corpus))
+	}
+	
+	neighbors := nearestNeighbors(pool, similarNeighbors)
+	order := []int{0}
+	visited := map[int]bool{0: true}
+	for current := 0; len(order) < count; {
+		next := -1
+		for _, candidate := range neighbors[current] {
+			if !visited[candidate] {
+				next = candidate
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		order = append(order, next)
+		visited[next] = true
+		current = next
+	}
+	fmt.Printf("Подобрано %d скороговорок. Следите за тем, чем каждая отличается от предыдущей.\n\n", len(order))
+	
+	for i, index := range order {
+		twister := pool[index]
+		fmt.Printf("Скороговорка %d из %d:\n", i+1, len(order))
+		fmt.Printf("Сложность: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
+		if i > 0 {
+			fmt.Printf("Сходство с предыдущей: %.0f%%\n", textSimilarity(pool[order[i-1]].Text, twister.Text)*100)
+		}
+		fmt.Println()
+		fmt.Println(twister.Text)
+		fmt.Println()
+		transcript.Record("shown", twisterID(twister), "")
+		
+		fmt.Println("Прочитайте скороговорку вслух и нажмите Enter...")
+		waitForEnter()
+		transcript.Record("finished", twisterID(twister), "")
+		fmt.Println(separator())
+	}
+	
+	fmt.Println("=== Тренировка завершена ===")
+}
+
+// nearestNeighbors returns, for every twister, the indices of up to k other twisters
+// ordered from the most to the least similar by normalized edit distance (see textSimilarity).
+// It compares every pair, so callers should cap the input (see similarCorpusLimit).
+func nearestNeighbors(twisters []TongueTwister, k int) [][]int {
+	normalized := make([][]rune, len(twisters))
+	for i, twister := range twisters {
+		normalized[i] = []rune(normalizeForComparison(twister.Text))
+	}
+	
+	distance := make([][]float64, len(twisters))
+	for i := range distance {
+		distance[i] = make([]float64, len(twisters))
+	}
+	for i := range twisters {
+		for j := i + 1; j < len(twisters); j++ {
+			longest := len(normalized[i])
+			if len(normalized[j]) > longest {
+				longest = len(normalized[j])
+			}
+			d := 0.0
+			if longest > 0 {
+				d = float64(levenshtein(normalized[i], normalized[j])) / float64(longest)
+			}
+			distance[i][j], distance[j][i] = d, d
+		}
+	}
+	
+	neighbors := make([][]int, len(twisters))
+	for i := range twisters {
+		others := make([]int, 0, len(twisters)-1)
+		for j := range twisters {
+			if j != i {
+				others = append(others, j)
+			}
+		}
+		sort.SliceStable(others, func(a, b int) bool {
+			return distance[i][others[a]] < distance[i][others[b]]
+		})
+		neighbors[i] = others[:min(k, len(others))]
+	}
+	return neighbors
+}
+
+// runVariationsTrainingSession drills each twister as written and then in a few generated
+// variations, which are clearly marked as exercises rather than the original text
+func runVariationsTrainingSession(twisters []TongueTwister) {
+	fmt.Println("=== Начинаем тренировку с вариациями ===")
+	fmt.Printf("Выбрано %d скороговорок. После оригинала идут упражнения: части переставлены или повторены.\n\n", len(twisters))
 	
 	for i, twister := range twisters {
 		fmt.Printf("Скороговорка %d из %d:\n", i+1, len(twisters))
-		fmt.Printf("Сложность: %s (%.1f)\n", getDifficultyLevel(twister.Score), twister.Score)
-		fmt.Printf("Статистика: %d слов, %d букв (%d гласных, %d согласных)\n", 
-			twister.Stats.WordCount, twister.Stats.CharCount, 
-			twister.Stats.VowelCount, twister.Stats.ConsonantCount)
+		fmt.Printf("Сложность: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
 		fmt.Println()
 		fmt.Println(twister.Text)
 		fmt.Println()
+		transcript.Record("shown", twisterID(twister), "")
+		fmt.Println("Прочитайте оригинал и нажмите Enter...")
+		waitForEnter()
 		
-		fmt.Println("Нажмите Enter, когда будете готовы начать испытание...")
-		bufio.NewReader(os.Stdin).ReadBytes('\n')
+		variations := generateVariations(twister.Text)
+		for v, variation := range variations {
+			fmt.Printf("Упражнение %d из %d (не оригинальный текст):\n", v+1, len(variations))
+			fmt.Println(variation)
+			fmt.Println()
+			transcript.Record("variation", twisterID(twister), strconv.Itoa(v+1))
+			waitForEnter()
+		}
+		if len(variations) == 0 {
+			fmt.Println("Для этой скороговорки вариаций нет: она слишком короткая.")
+		}
+		transcript.Record("finished", twisterID(twister), "")
+		fmt.Println(separator())
+	}
+	
+	fmt.Println("=== Тренировка завершена ===")
+}
+
+// generateVariations returns deterministic drill versions of the text: its parts in reverse
+// order, the hardest word said twice, and the hardest part on its own. Parts are the lines of
+// a multi-line twister, or its clauses otherwise. Variations that would repeat the original
+// or each other are left out.
+func generateVariations(text string) []string {
+	var variations []string
+	add := func(variation string) {
+		if variation == "" || variation == text {
+			return
+		}
+		for _, existing := range variations {
+			if existing == variation {
+				return
+			}
+		}
+		variations = append(variations, variation)
+	}
+	
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	parts, separator := lines, "\n"
+	if len(lines) < 2 {
+		parts, separator = splitClauses(text), " "
+	}
+	
+	if len(parts) >= 2 {
+		reversed := make([]string, len(parts))
+		for i, part := range parts {
+			reversed[len(parts)-1-i] = capitalizeFirst(part)
+		}
+		add(strings.Join(reversed, separator))
+	}
+	
+	// Double the hardest word in place, keeping the line breaks
+	hardestLine, hardestWord, hardestWeight := -1, -1, -1.0
+	for l, line := range lines {
+		words := strings.Fields(line)
+		if w := hardestWordIndex(words); w >= 0 && textDifficultyWeight(words[w]) > hardestWeight {
+			hardestLine, hardestWord, hardestWeight = l, w, textDifficultyWeight(words[w])
+		}
+	}
+	if hardestLine >= 0 {
+		doubledLines := append([]string{}, lines...)
+		words := strings.Fields(lines[hardestLine])
+		doubled := append([]string{}, words[:hardestWord]...)
+		doubled = append(doubled, strings.TrimFunc(words[hardestWord], unicode.IsPunct), words[hardestWord])
+		doubled = append(doubled, words[hardestWord+1:]...)
+		doubledLines[hardestLine] = strings.Join(doubled, " ")
+		add(strings.Join(doubledLines, "\n"))
+	}
+	
+	if len(parts) >= 2 {
+		hardest, hardestPartWeight := "", -1.0
+		for _, part := range parts {
+			if weight := textDifficultyWeight(part); weight > hardestPartWeight {
+				hardest, hardestPartWeight = part, weight
+			}
+		}
+		add(capitalizeFirst(hardest))
+	}
+	return variations
+}
+
+// hardestWordIndex returns the index of the word with the largest total difficulty
+// (see charDifficultyWeights), or -1 if there are no words
+func hardestWordIndex(words []string) int {
+	hardest, hardestWeight := -1, -1.0
+	for i, word := range words {
+		if weight := textDifficultyWeight(word); weight > hardestWeight {
+			hardest, hardestWeight = i, weight
+		}
+	}
+	return hardest
+}
+
+// textDifficultyWeight sums the per-letter difficulty weights of the text
+func textDifficultyWeight(text string) float64 {
+	total := 0.0
+	for _, weight := range charDifficultyWeights(text) {
+		total += weight
+	}
+	return total
+}
+
+// capitalizeFirst upper-cases the first letter of the text
+func capitalizeFirst(text string) string {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return text
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// runChallengeTrainingSession conducts a challenging training session with increasing speed
+func runChallengeTrainingSession(twisters, pool []TongueTwister) {
+	fmt.Println("=== Начинаем тренировку-вызов ===")
+	fmt.Printf("Выбрано %d скороговорок для практики. Повторяйте каждую с увеличением скорости.\n\n", len(twisters))
+	
+	setSize := len(twisters)
+	sessionSampler.Mark(twisters...)
+	for {
+		for i, twister := range twisters {
+			runChallengeTwister(twister, i+1, len(twisters))
+		}
 		
-		for s, speed := range speeds {
-			fmt.Printf("\rЧтение #%d: %s. Нажмите Enter после прочтения...", s+1, speed)
-			bufio.NewReader(os.Stdin).ReadBytes('\n')
+		// Offer to keep going until the user quits
+		hardest := twisters[0]
+		for _, twister := range twisters[1:] {
+			if twister.Score > hardest.Score {
+				hardest = twister
+			}
 		}
+		fmt.Println("Что дальше?")
+		fmt.Printf("  1 — повторить самую сложную (№%s, %.1f)\n", twisterID(hardest), hardest.Score)
+		fmt.Println("  2 — новый набор скороговорок")
+		fmt.Println("  Enter — закончить")
 		
-		fmt.Println("\nВы справились с вызовом!")
-		fmt.Println(strings.Repeat("-", 60))
+		switch readChoice() {
+		case "1":
+			twisters = []TongueTwister{hardest}
+		case "2":
+			twisters = sessionSampler.Sample(pool, setSize)
+			fmt.Printf("\nНовый набор: %d скороговорок.\n\n", len(twisters))
+		default:
+			fmt.Println("=== Тренировка завершена ===")
+			return
+		}
+		fmt.Println()
+	}
+}
+
+// runChallengeTwister walks one twister through the increasing challenge speeds
+func runChallengeTwister(twister TongueTwister, index, total int) {
+	speeds := []string{"Медленно", "Средне", "Быстро", "Очень быстро"}
+	
+	fmt.Printf("Скороговорка %d из %d:\n", index, total)
+	fmt.Printf("Сложность: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
+	fmt.Printf("Статистика: %d слов, %d букв (%d гласных, %d согласных)\n", 
+		twister.Stats.WordCount, twister.Stats.CharCount, 
+		twister.Stats.VowelCount, twister.Stats.ConsonantCount)
+	fmt.Println()
+	fmt.Println(twister.Text)
+	fmt.Println()
+	transcript.Record("shown", twisterID(twister), "")
+	
+	fmt.Println("Нажмите Enter, когда будете готовы начать испытание...")
+	waitForEnter()
+	transcript.Record("started", twisterID(twister), "")
+	
+	for s, speed := range speeds {
+		fmt.Printf("\rЧтение #%d: %s. Нажмите Enter после прочтения...", s+1, speed)
+		waitForEnter()
+		transcript.Record("reading", twisterID(twister), speed)
 	}
+	transcript.Record("finished", twisterID(twister), "")
 	
-	fmt.Println("=== Тренировка завершена ===")
+	fmt.Println("\nВы справились с вызовом!")
+	fmt.Println(separator())
+}
+
+// focusRoundOffsets — сколько раундов добавляется к уровню по умолчанию для каждого фокуса:
+// для скорости лучше больше коротких подходов, для дыхания — меньше, но длиннее
+var focusRoundOffsets = []int{2, 2, 2, 1, 4}
+
+// PerfectionOptions holds the settings of a perfection training session
+type PerfectionOptions struct {
+	FocusArea  int              // Индекс в dictionFocusAreas
+	Level      int              // Уровень требований от 1 до 5
+	Adaptive   bool             // Корректировать сложность раундов по оценкам
+	ScoreDecay float64          // Коэффициент затухания для среднего балла
+	Rounds     int              // Количество раундов; 0 — по уровню и фокусу (Level + focusRoundOffsets)
+	Profile    *UserPerformance // Профиль пользователя; nil — новый профиль только на эту сессию
+	// Повторить все раунды с теми же скороговорками и сравнить оценки
+	SecondAttempt bool
+	// Распознавание речи для автоматической оценки; nil — самооценка
+	Recognizer Recognizer
+	// Каталог для отчёта о сессии (см. -aggregate); пусто — отчёт не сохраняется
+	ReportDir string
+	// Сколько раз за сессию можно открыть советы к раунду; отрицательное значение — советы всегда видны
+	Hints int
+	// Состояние прерванной сессии, которую нужно продолжить (см. -resume-session); nil — новая сессия
+	Resume *SessionState
+}
+
+// sessionStateFileName — имя файла, в который тренировка идеальной дикции сохраняется после каждого раунда
+const sessionStateFileName = "session_state.json"
+
+// sessionStatePath — путь к файлу состояния сессии; задаётся в main через sessionStateLocation
+var sessionStatePath = sessionStateFileName
+
+// sessionStateLocation выбирает место для файла состояния сессии. С профилем это файл рядом с ним
+// с именем профиля (a.json → a.session.json), чтобы у профилей из одного каталога не было общей
+// сессии; без профиля — пользовательский каталог кэша, а без него — текущий каталог
+func sessionStateLocation(profilePath string) string {
+	if profilePath != "" {
+		return strings.TrimSuffix(profilePath, filepath.Ext(profilePath)) + ".session.json"
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(cacheDir, "easy_trainer", sessionStateFileName)
+	}
+	return sessionStateFileName
+}
+
+// SessionState хранит всё, что нужно, чтобы продолжить прерванную тренировку со следующего раунда
+type SessionState struct {
+	FocusArea    int             `json:"focus_area"`
+	Level        int             `json:"level"`
+	Adaptive     bool            `json:"adaptive"`
+	TotalRounds  int             `json:"total_rounds"`
+	Twisters     []TongueTwister `json:"twisters"`     // Скороговорки, из которых выбираются раунды
+	Rounds       []RoundResult   `json:"rounds"`       // Пройденные раунды с оценками
+	Difficulties []float64       `json:"difficulties"` // Сложность всех раундов с учётом адаптации
+	HintsUsed    int             `json:"hints_used"`
+	ScoreDecay   float64         `json:"score_decay,omitempty"` // Коэффициент затухания среднего балла; 0 в файлах старых версий
+}
+
+// saveSessionState записывает состояние сессии; файл подменяется целиком,
+// чтобы прерывание во время записи не оставило его испорченным
+func saveSessionState(path string, state SessionState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadSessionState читает состояние прерванной сессии
+func loadSessionState(path string) (*SessionState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("нет сохранённой сессии (%s)", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if state.TotalRounds < 1 || len(state.Difficulties) != state.TotalRounds || len(state.Rounds) > state.TotalRounds ||
+		state.FocusArea < 0 || state.FocusArea >= len(dictionFocusAreas) || len(state.Twisters) == 0 {
+		return nil, fmt.Errorf("%s повреждён или несовместим с этой версией", path)
+	}
+	return &state, nil
+}
+
+// RoundResult хранит итог одного раунда тренировки
+type RoundResult struct {
+	Twister    TongueTwister `json:"twister"`
+	Score      int           `json:"score"`
+	Difficulty float64       `json:"difficulty"`
 }
 
 // runPerfectionTrainingSession conducts a training session focused on perfecting diction and pronunciation
-func runPerfectionTrainingSession(twisters []TongueTwister, focusArea int, perfectionLevel int) {
+func runPerfectionTrainingSession(twisters []TongueTwister, opts PerfectionOptions) {
+	focusArea := opts.FocusArea
+	perfectionLevel := opts.Level
+	adaptive := opts.Adaptive
 	focus := dictionFocusAreas[focusArea]
 	
 	fmt.Println("=== Начинаем тренировку идеальной дикции ===")
@@ -618,7 +3887,11 @@ func runPerfectionTrainingSession(twisters []TongueTwister, focusArea int, perfe
 	fmt.Printf("Уровень требований: %d из 5\n\n", perfectionLevel)
 	
 	// Создаем профиль пользователя для этой сессии
-	userProfile := NewUserPerformance()
+	userProfile := opts.Profile
+	if userProfile == nil {
+		userProfile = NewUserPerformance()
+	}
+	userProfile.ScoreDecay = opts.ScoreDecay
 	
 	// Анализируем имеющиеся скороговорки для более умного выбора
 	categorizedTwisters := categorizeTwistersForTraining(twisters, focusArea)
@@ -649,70 +3922,688 @@ func runPerfectionTrainingSession(twisters []TongueTwister, focusArea int, perfe
 	}
 	fmt.Println()
 	
-	// Динамически определяем количество раундов в зависимости от уровня
-	totalRounds := perfectionLevel + 2
+	// Динамически определяем количество раундов в зависимости от уровня и фокуса, если оно не задано явно
+	totalRounds := perfectionLevel + focusRoundOffsets[focusArea]
+	if opts.Rounds > 0 {
+		totalRounds = opts.Rounds
+	}
 	
 	// Определяем прогрессию сложности
 	difficulties := generateDifficultyProgression(perfectionLevel, totalRounds, 1.0)
 	
-	fmt.Println("Тренировка состоит из нескольких раундов с адаптивной сложностью")
-	fmt.Println("Система будет подбирать скороговорки на основе вашего прогресса")
+	if adaptive {
+		fmt.Println("Тренировка состоит из нескольких раундов с адаптивной сложностью")
+		fmt.Println("Система будет подбирать скороговорки на основе вашего прогресса")
+	} else {
+		fmt.Println("Тренировка состоит из нескольких раундов с фиксированной прогрессией сложности")
+		fmt.Println("Ваши оценки не будут влиять на сложность следующих раундов")
+	}
 	fmt.Println()
 	
 	totalScore := 0
+	hintsUsed := 0
 	
-	for round := 1; round <= totalRounds; round++ {
+	// Состояние профиля до сессии — от него пересчитываются исправленные оценки
+	before := snapshotProfile(userProfile)
+	
+	// Результаты пройденных раундов
+	var rounds []RoundResult
+	
+	// При продолжении прерванной сессии восстанавливаем пройденные раунды и их вклад в профиль
+	if opts.Resume != nil {
+		totalRounds = opts.Resume.TotalRounds
+		difficulties = opts.Resume.Difficulties
+		rounds = opts.Resume.Rounds
+		hintsUsed = opts.Resume.HintsUsed
+		for _, result := range rounds {
+			totalScore += result.Score
+			userProfile.LastScores = append(userProfile.LastScores, result.Score)
+			updateUserPerformance(userProfile, result.Twister, result.Score, focusArea)
+		}
+		fmt.Printf("Продолжаем прерванную тренировку: пройдено раундов %d из %d\n\n", len(rounds), totalRounds)
+	}
+	
+	for round := len(rounds) + 1; round <= totalRounds; round++ {
 		// Выбираем наиболее подходящую скороговорку для текущего раунда
-		twister := selectOptimalTwister(categorizedTwisters, userProfile, round, totalRounds, focusArea)
+		twister, err := selectOptimalTwister(categorizedTwisters, userProfile, round, totalRounds, focusArea, adaptive)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
 		
 		// Определяем текущую сложность
 		currentDifficulty := difficulties[round-1]
 		
 		fmt.Printf("=== Раунд %d из %d (сложность %.1f) ===\n", round, totalRounds, currentDifficulty)
-		fmt.Printf("Скороговорка: %s (%.1f)\n", getDifficultyLevel(twister.Score), twister.Score)
+		fmt.Printf("Скороговорка: %s (%.1f, сложнее %d%% скороговорок)\n", twisterLevel(twister), twister.Score, twister.Percentile)
+		
+		// После двух низких оценок подряд добавляем упражнение на проговаривание по слогам
+		if needsRemedialExercise(userProfile.LastScores) {
+			runSyllableExercise(twister.Text)
+		}
 		
 		// Выводим специфические особенности скороговорки в зависимости от фокуса тренировки
 		presentTwisterFeatures(twister, focusArea)
 		
+		// Длинные фразы при тренировке дыхания открываем постепенно, по частям
+		if focusArea == 3 && twister.Stats.CharCount > 60 {
+			revealClauses(twister.Text)
+		}
+		
 		fmt.Println()
 		fmt.Println(twister.Text)
 		fmt.Println()
+		transcript.Record("shown", twisterID(twister), "")
 		
-		// Даем конкретные советы по работе над этой скороговоркой
-		provideFocusedAdvice(twister, focusArea, round, currentDifficulty)
+		// Даем конкретные советы по работе над этой скороговоркой — сразу или из бюджета подсказок
+		if opts.Hints < 0 {
+			provideFocusedAdvice(twister, focusArea, round, currentDifficulty)
+		} else if hintsUsed < opts.Hints {
+			fmt.Printf("h — показать подсказку (осталось %d), Enter — без подсказки: ", opts.Hints-hintsUsed)
+			if choice := strings.ToLower(readChoice()); choice == "h" || choice == "х" {
+				hintsUsed++
+				transcript.Record("hint", twisterID(twister), "")
+				provideFocusedAdvice(twister, focusArea, round, currentDifficulty)
+			}
+		} else {
+			fmt.Println("Подсказки закончились.")
+		}
 		
 		fmt.Println("\nНажмите Enter, когда будете готовы прочитать скороговорку...")
-		bufio.NewReader(os.Stdin).ReadBytes('\n')
+		waitForEnter()
+		transcript.Record("started", twisterID(twister), "")
 		
-		// Оценка производительности
-		fmt.Print("Оцените свое произношение от 1 до 5: ")
-		var score int
-		fmt.Scanln(&score)
-		if score < 1 {
-			score = 1
-		} else if score > 5 {
-			score = 5
-		}
+		// Оценка производительности: распознаванием речи, если оно настроено, иначе самооценкой
+		score := scoreAttempt(opts.Recognizer, twister.Text)
+		transcript.Record("score", twisterID(twister), strconv.Itoa(score))
 		
 		totalScore += score
 		userProfile.LastScores = append(userProfile.LastScores, score)
+		rounds = append(rounds, RoundResult{Twister: twister, Score: score, Difficulty: currentDifficulty})
+		
+		// Обновляем статистику пользователя
+		updateUserPerformance(userProfile, twister, score, focusArea)
+		
+		// Адаптивно корректируем последующие раунды в зависимости от производительности
+		if adaptive && round < totalRounds {
+			difficulties = adjustDifficulties(difficulties, round, score)
+		}
+		
+		// Обратная связь и рекомендации
+		provideFeedback(score, twister, focusArea)
+		
+		// Автосохранение: после прерывания сессию можно продолжить с -resume-session
+		state := SessionState{
+			FocusArea:    focusArea,
+			Level:        perfectionLevel,
+			Adaptive:     adaptive,
+			TotalRounds:  totalRounds,
+			Twisters:     twisters,
+			Rounds:       rounds,
+			Difficulties: difficulties,
+			HintsUsed:    hintsUsed,
+			ScoreDecay:   opts.ScoreDecay,
+		}
+		if err := saveSessionState(sessionStatePath, state); err != nil {
+			fmt.Printf("Не удалось сохранить состояние сессии: %v\n", err)
+		}
+		
+		fmt.Println(separator())
+	}
+	
+	userProfile.TotalSessions++
+	
+	// Анализ результатов сессии
+	analyzeTrainingResults(userProfile, totalScore, totalRounds, focusArea, rounds)
+	if opts.Hints >= 0 {
+		fmt.Printf("\nПодсказок использовано: %d из %d\n", hintsUsed, opts.Hints)
+	}
+	
+	// Даём исправить случайно введённые оценки, пока они не попали в профиль и отчёт
+	if reviewRoundScores(rounds) {
+		before.restore(userProfile)
+		totalScore = 0
+		for _, result := range rounds {
+			totalScore += result.Score
+			userProfile.LastScores = append(userProfile.LastScores, result.Score)
+			updateUserPerformance(userProfile, result.Twister, result.Score, focusArea)
+		}
+		fmt.Printf("Исправленный средний балл: %.1f из 5.0 (с учётом последних раундов: %.1f)\n",
+			float64(totalScore)/float64(len(rounds)), userProfile.AverageScore)
+	}
+	
+	if opts.SecondAttempt {
+		runSecondAttempt(rounds, userProfile, focusArea, opts.Recognizer)
+	}
+	
+	report := SessionReport{Date: time.Now(), Focus: focus.ID, Rounds: rounds}
+	awardAchievements(userProfile, report)
+	
+	if opts.ReportDir != "" {
+		path, err := saveSessionReport(opts.ReportDir, report)
+		if err != nil {
+			fmt.Printf("Не удалось сохранить отчёт: %v\n", err)
+		} else {
+			fmt.Printf("Отчёт о сессии сохранён в %s\n", path)
+		}
+	}
+	
+	// Сессия завершена, продолжать больше нечего
+	if err := os.Remove(sessionStatePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Не удалось удалить %s: %v\n", sessionStatePath, err)
+	}
+}
+
+// profileSnapshot хранит изменяемую за сессию часть профиля
+type profileSnapshot struct {
+	scoreCount       int
+	averageScore     float64
+	successRate      map[string]float64
+	difficultyRating map[string]float64
+}
+
+// snapshotProfile запоминает оценки и рейтинги профиля перед началом сессии
+func snapshotProfile(profile *UserPerformance) profileSnapshot {
+	snapshot := profileSnapshot{
+		scoreCount:       len(profile.LastScores),
+		averageScore:     profile.AverageScore,
+		successRate:      make(map[string]float64, len(profile.SuccessRate)),
+		difficultyRating: make(map[string]float64, len(profile.DifficultyRating)),
+	}
+	for key, value := range profile.SuccessRate {
+		snapshot.successRate[key] = value
+	}
+	for key, value := range profile.DifficultyRating {
+		snapshot.difficultyRating[key] = value
+	}
+	return snapshot
+}
+
+// restore возвращает профиль к запомненному состоянию, отбрасывая оценки сессии
+func (s profileSnapshot) restore(profile *UserPerformance) {
+	profile.LastScores = profile.LastScores[:s.scoreCount]
+	profile.AverageScore = s.averageScore
+	profile.SuccessRate = make(map[string]float64, len(s.successRate))
+	for key, value := range s.successRate {
+		profile.SuccessRate[key] = value
+	}
+	profile.DifficultyRating = make(map[string]float64, len(s.difficultyRating))
+	for key, value := range s.difficultyRating {
+		profile.DifficultyRating[key] = value
+	}
+}
+
+// reviewRoundScores показывает оценки раундов и принимает исправления вида «номер оценка»
+// до пустого ввода. Возвращает true, если хотя бы одна оценка изменилась
+func reviewRoundScores(rounds []RoundResult) bool {
+	if len(rounds) == 0 || nonInteractive.Enabled {
+		return false
+	}
+	
+	changed := false
+	for {
+		fmt.Println("\n=== Проверка оценок ===")
+		for i, result := range rounds {
+			fmt.Printf("%d. №%s (%s): %d\n", i+1, twisterID(result.Twister), twisterLevel(result.Twister), result.Score)
+		}
+		fmt.Print("Чтобы исправить оценку, введите номер раунда и новую оценку (например, «2 4»), Enter — всё верно: ")
+		
+		choice := readChoice()
+		if choice == "" {
+			return changed
+		}
+		fields := strings.Fields(choice)
+		if len(fields) != 2 {
+			fmt.Println("Нужно два числа: номер раунда и оценка.")
+			continue
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil || index < 1 || index > len(rounds) {
+			fmt.Printf("Номер раунда должен быть от 1 до %d.\n", len(rounds))
+			continue
+		}
+		score, err := strconv.Atoi(fields[1])
+		if err != nil || score < 1 || score > 5 {
+			fmt.Println("Оценка должна быть от 1 до 5.")
+			continue
+		}
+		
+		result := &rounds[index-1]
+		if result.Score != score {
+			transcript.Record("score-fix", twisterID(result.Twister), fmt.Sprintf("%d->%d", result.Score, score))
+			result.Score = score
+			changed = true
+		}
+	}
+}
+
+// runSecondAttempt повторяет раунды с теми же скороговорками в том же порядке
+// и сравнивает новые оценки с оценками первой попытки
+func runSecondAttempt(rounds []RoundResult, profile *UserPerformance, focusArea int, recognizer Recognizer) {
+	if len(rounds) == 0 {
+		return
+	}
+	
+	fmt.Println()
+	fmt.Println("=== Вторая попытка ===")
+	fmt.Println("Те же скороговорки в том же порядке. Посмотрим, что изменилось.")
+	fmt.Println()
+	
+	secondScores := make([]int, len(rounds))
+	for i, result := range rounds {
+		fmt.Printf("=== Повтор раунда %d из %d (первая оценка: %d) ===\n", i+1, len(rounds), result.Score)
+		presentTwisterFeatures(result.Twister, focusArea)
+		
+		fmt.Println()
+		fmt.Println(result.Twister.Text)
+		fmt.Println()
+		transcript.Record("shown", twisterID(result.Twister), "second attempt")
+		
+		fmt.Println("Нажмите Enter, когда будете готовы прочитать скороговорку...")
+		waitForEnter()
+		transcript.Record("started", twisterID(result.Twister), "second attempt")
+		
+		score := scoreAttempt(recognizer, result.Twister.Text)
+		transcript.Record("score", twisterID(result.Twister), strconv.Itoa(score))
+		secondScores[i] = score
+		
+		profile.LastScores = append(profile.LastScores, score)
+		updateUserPerformance(profile, result.Twister, score, focusArea)
+		
+		fmt.Println(separator())
+	}
+	
+	// Сравнение попыток по каждой скороговорке
+	fmt.Println("=== Сравнение попыток ===")
+	firstTotal, secondTotal := 0, 0
+	for i, result := range rounds {
+		delta := secondScores[i] - result.Score
+		verdict := "без изменений"
+		if delta > 0 {
+			verdict = "улучшение"
+		} else if delta < 0 {
+			verdict = "ухудшение"
+		}
+		fmt.Printf("Раунд %d (№%s): %d → %d (%+d, %s)\n",
+			i+1, twisterID(result.Twister), result.Score, secondScores[i], delta, verdict)
+		firstTotal += result.Score
+		secondTotal += secondScores[i]
+	}
+	
+	firstAvg := float64(firstTotal) / float64(len(rounds))
+	secondAvg := float64(secondTotal) / float64(len(rounds))
+	fmt.Printf("\nСредний балл: %.1f → %.1f (%+.1f)\n", firstAvg, secondAvg, secondAvg-firstAvg)
+	switch {
+	case secondAvg > firstAvg:
+		fmt.Println("Общее улучшение — повторение работает!")
+	case secondAvg < firstAvg:
+		fmt.Println("Общее ухудшение — возможно, стоит сделать перерыв.")
+	default:
+		fmt.Println("Результат стабилен.")
+	}
+}
+
+// saveSessionReport записывает отчёт в каталог dir под именем по времени сессии и возвращает путь к файлу
+func saveSessionReport(dir string, report SessionReport) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	path := filepath.Join(dir, "session-"+report.Date.Format("20060102-150405")+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// loadSessionReports читает все отчёты *.json из каталога dir
+func loadSessionReports(dir string) ([]SessionReport, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	
+	var reports []SessionReport
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		var report SessionReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// DailyAverage — средний балл за один день
+type DailyAverage struct {
+	Day      string // ГГГГ-ММ-ДД
+	Sessions int
+	Average  float64
+}
+
+// TwisterPractice — сколько раз скороговорка встречалась в раундах
+type TwisterPractice struct {
+	Number string
+	Text   string
+	Rounds int
+}
+
+// Aggregate — сводка по многим отчётам о сессиях
+type Aggregate struct {
+	Sessions      int
+	Rounds        int
+	Average       float64           // Средний балл по всем раундам
+	Daily         []DailyAverage    // По возрастанию даты
+	FocusSessions map[string]int    // Число сессий по ID фокуса
+	MostPracticed []TwisterPractice // Самые частые скороговорки, по убыванию
+}
+
+// aggregateMostPracticed ограничивает список самых частых скороговорок в сводке
+const aggregateMostPracticed = 5
+
+// aggregateReports сводит отчёты о сессиях: средний балл по дням, сессии по фокусам
+// и самые часто тренируемые скороговорки
+func aggregateReports(reports []SessionReport) Aggregate {
+	aggregate := Aggregate{Sessions: len(reports), FocusSessions: make(map[string]int)}
+	
+	dayTotals := make(map[string]int)
+	dayRounds := make(map[string]int)
+	daySessions := make(map[string]int)
+	practice := make(map[string]*TwisterPractice)
+	total := 0
+	for _, report := range reports {
+		day := report.Date.Format("2006-01-02")
+		daySessions[day]++
+		if report.Focus != "" {
+			aggregate.FocusSessions[report.Focus]++
+		}
+		for _, round := range report.Rounds {
+			dayTotals[day] += round.Score
+			dayRounds[day]++
+			total += round.Score
+			aggregate.Rounds++
+			
+			entry, ok := practice[twisterID(round.Twister)]
+			if !ok {
+				entry = &TwisterPractice{Number: twisterID(round.Twister), Text: round.Twister.Text}
+				practice[twisterID(round.Twister)] = entry
+			}
+			entry.Rounds++
+		}
+	}
+	if aggregate.Rounds > 0 {
+		aggregate.Average = float64(total) / float64(aggregate.Rounds)
+	}
+	
+	for day, sessions := range daySessions {
+		average := 0.0
+		if dayRounds[day] > 0 {
+			average = float64(dayTotals[day]) / float64(dayRounds[day])
+		}
+		aggregate.Daily = append(aggregate.Daily, DailyAverage{Day: day, Sessions: sessions, Average: average})
+	}
+	sort.Slice(aggregate.Daily, func(i, j int) bool {
+		return aggregate.Daily[i].Day < aggregate.Daily[j].Day
+	})
+	
+	for _, entry := range practice {
+		aggregate.MostPracticed = append(aggregate.MostPracticed, *entry)
+	}
+	sort.Slice(aggregate.MostPracticed, func(i, j int) bool {
+		a, b := aggregate.MostPracticed[i], aggregate.MostPracticed[j]
+		if a.Rounds != b.Rounds {
+			return a.Rounds > b.Rounds
+		}
+		return a.Number < b.Number
+	})
+	if len(aggregate.MostPracticed) > aggregateMostPracticed {
+		aggregate.MostPracticed = aggregate.MostPracticed[:aggregateMostPracticed]
+	}
+	return aggregate
+}
+
+// printAggregate выводит сводку по отчётам
+func printAggregate(aggregate Aggregate) {
+	fmt.Println("=== Сводка по сессиям ===")
+	if aggregate.Sessions == 0 {
+		fmt.Println("Отчётов не найдено.")
+		return
+	}
+	fmt.Printf("Сессий: %d, раундов: %d, средний балл: %.1f\n", aggregate.Sessions, aggregate.Rounds, aggregate.Average)
+	
+	fmt.Println("\nСредний балл по дням:")
+	for _, day := range aggregate.Daily {
+		fmt.Printf("  %s  %.1f  (сессий: %d)\n", day.Day, day.Average, day.Sessions)
+	}
+	
+	fmt.Println("\nСессии по фокусу:")
+	for _, focus := range dictionFocusAreas {
+		if count := aggregate.FocusSessions[focus.ID]; count > 0 {
+			fmt.Printf("  %s: %d\n", focus.Name, count)
+		}
+	}
+	
+	fmt.Println("\nЧаще всего тренировались:")
+	for _, twister := range aggregate.MostPracticed {
+		fmt.Printf("  №%s (раундов: %d): %s\n", twister.Number, twister.Rounds, strings.Join(strings.Fields(twister.Text), " "))
+	}
+}
+
+// writeAggregateCSV записывает средний балл по дням в CSV-файл
+func writeAggregateCSV(path string, aggregate Aggregate) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+	
+	w := csv.NewWriter(file)
+	w.Write([]string{"day", "sessions", "average_score"})
+	for _, day := range aggregate.Daily {
+		w.Write([]string{day.Day, strconv.Itoa(day.Sessions), strconv.FormatFloat(day.Average, 'f', 2, 64)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Recognizer распознаёт произнесённую пользователем фразу
+type Recognizer interface {
+	Recognize() (string, error)
+}
+
+// CommandRecognizer распознаёт речь внешней командой: команда запускается через sh -c,
+// записывает речь и печатает распознанный текст в stdout
+type CommandRecognizer struct {
+	Command string
+}
+
+// Recognize запускает команду и возвращает её вывод
+func (r CommandRecognizer) Recognize() (string, error) {
+	cmd := exec.Command("sh", "-c", r.Command)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("recognizer command failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// scoreAttempt оценивает попытку по распознанной речи, а без распознавания
+// или при его ошибке просит пользователя оценить себя самому
+func scoreAttempt(recognizer Recognizer, text string) int {
+	if recognizer != nil {
+		fmt.Println("Говорите...")
+		recognized, err := recognizer.Recognize()
+		if err == nil {
+			similarity := textSimilarity(text, recognized)
+			score := similarityScore(similarity)
+			fmt.Printf("Распознано: %s\n", recognized)
+			fmt.Printf("Совпадение с текстом: %.0f%%, оценка: %d\n", similarity*100, score)
+			return score
+		}
+		fmt.Printf("Не удалось распознать речь: %v\n", err)
+	}
+	
+	fmt.Print("Оцените свое произношение от 1 до 5: ")
+	return readSelfScore()
+}
+
+// similarityScore переводит долю совпадения текста (0-1) в оценку от 1 до 5
+func similarityScore(similarity float64) int {
+	switch {
+	case similarity >= 0.95:
+		return 5
+	case similarity >= 0.85:
+		return 4
+	case similarity >= 0.7:
+		return 3
+	case similarity >= 0.5:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// textSimilarity возвращает 1 минус нормализованное расстояние Левенштейна между текстами.
+// Регистр, знаки препинания, лишние пробелы и различие е/ё не учитываются.
+func textSimilarity(expected, recognized string) float64 {
+	a := []rune(normalizeForComparison(expected))
+	b := []rune(normalizeForComparison(recognized))
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// normalizeForComparison оставляет только буквы и цифры в нижнем регистре, разделённые одним пробелом
+func normalizeForComparison(text string) string {
+	var words []string
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		var b strings.Builder
+		for _, char := range field {
+			if char == 'ё' {
+				char = 'е'
+			}
+			if unicode.IsLetter(char) || unicode.IsDigit(char) {
+				b.WriteRune(char)
+			}
+		}
+		if b.Len() > 0 {
+			words = append(words, b.String())
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// levenshtein возвращает минимальное число вставок, удалений и замен символов, превращающих a в b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// drillFocusAreas — аспекты, которые по кругу отрабатываются в режиме drill: артикуляция, ритм, скорость
+var drillFocusAreas = []int{0, 1, 4}
+
+// runDrillSession многократно отрабатывает одну скороговорку, чередуя фокус тренировки,
+// и в конце сообщает, какой аспект получил самые низкие оценки
+func runDrillSession(twister TongueTwister, totalRounds int) {
+	fmt.Println("=== Начинаем отработку одной скороговорки ===")
+	fmt.Printf("Скороговорка №%s: %s (%.1f, сложнее %d%% скороговорок)\n",
+		twisterID(twister), twisterLevel(twister), twister.Score, twister.Percentile)
+	fmt.Printf("Раундов: %d, фокус меняется по кругу\n\n", totalRounds)
+	
+	scores := make(map[int][]int)
+	
+	for round := 1; round <= totalRounds; round++ {
+		focusArea := drillFocusAreas[(round-1)%len(drillFocusAreas)]
+		focus := dictionFocusAreas[focusArea]
+		// Номер круга по этому аспекту определяет этап советов
+		aspectRound := (round-1)/len(drillFocusAreas) + 1
+		
+		fmt.Printf("=== Раунд %d из %d: %s ===\n", round, totalRounds, focus.Name)
+		fmt.Println(focus.Description)
+		presentTwisterFeatures(twister, focusArea)
+		
+		fmt.Println()
+		fmt.Println(twister.Text)
+		fmt.Println()
+		transcript.Record("shown", twisterID(twister), focus.Name)
 		
-		// Обновляем статистику пользователя
-		updateUserPerformance(userProfile, twister, score, focusArea)
+		provideFocusedAdvice(twister, focusArea, aspectRound, float64(aspectRound))
 		
-		// Адаптивно корректируем последующие раунды в зависимости от производительности
-		if round < totalRounds {
-			difficulties = adjustDifficulties(difficulties, round, score)
-		}
+		fmt.Println("\nНажмите Enter, когда будете готовы прочитать скороговорку...")
+		waitForEnter()
+		transcript.Record("started", twisterID(twister), focus.Name)
+		
+		fmt.Print("Оцените свое произношение от 1 до 5: ")
+		score := readSelfScore()
+		transcript.Record("score", twisterID(twister), strconv.Itoa(score))
+		scores[focusArea] = append(scores[focusArea], score)
 		
-		// Обратная связь и рекомендации
 		provideFeedback(score, twister, focusArea)
+		fmt.Println(separator())
+	}
+	
+	// Итоги по аспектам
+	fmt.Println("=== Итоги отработки ===")
+	weakestFocus := -1
+	weakestAvg := 0.0
+	for _, focusArea := range drillFocusAreas {
+		aspectScores := scores[focusArea]
+		if len(aspectScores) == 0 {
+			continue
+		}
+		total := 0
+		for _, score := range aspectScores {
+			total += score
+		}
+		avg := float64(total) / float64(len(aspectScores))
+		fmt.Printf("%s: средний балл %.1f (оценки: %v)\n", dictionFocusAreas[focusArea].Name, avg, aspectScores)
 		
-		fmt.Println(strings.Repeat("-", 60))
+		if weakestFocus == -1 || avg < weakestAvg {
+			weakestFocus = focusArea
+			weakestAvg = avg
+		}
 	}
 	
-	// Анализ результатов сессии
-	analyzeTrainingResults(userProfile, totalScore, totalRounds, focusArea)
+	if weakestFocus >= 0 {
+		fmt.Printf("\nСлабее всего получается: %s. Продолжите с -mode perfection -focus %d\n",
+			dictionFocusAreas[weakestFocus].Name, weakestFocus)
+	}
 }
 
 // categorizeTwistersForTraining классифицирует скороговорки по специфическим характеристикам
@@ -747,7 +4638,7 @@ func categorizeTwistersForTraining(twisters []TongueTwister, focusArea int) map[
 	// Классифицируем каждую скороговорку
 	for _, twister := range twisters {
 		// Классификация по уровню сложности
-		diff := getDifficultyLevel(twister.Score)
+		diff := twisterLevel(twister)
 		switch diff {
 		case Easy:
 			categories["easy"] = append(categories["easy"], twister)
@@ -816,6 +4707,15 @@ func containsAny(s string, chars []rune) bool {
 
 // generateDifficultyProgression создает прогрессию сложности для тренировки
 func generateDifficultyProgression(level, rounds int, startingDiff float64) []float64 {
+	// Без раундов прогрессии нет, а единственный раунд идёт с начальной сложностью
+	// (иначе шаг ниже делился бы на ноль и давал NaN/Inf)
+	if rounds < 1 {
+		return []float64{}
+	}
+	if rounds == 1 {
+		return []float64{startingDiff}
+	}
+	
 	result := make([]float64, rounds)
 	maxDiff := float64(level) * 1.5
 	
@@ -835,10 +4735,14 @@ func generateDifficultyProgression(level, rounds int, startingDiff float64) []fl
 	return result
 }
 
-// selectOptimalTwister выбирает оптимальную скороговорку для текущего этапа тренировки
-func selectOptimalTwister(categories map[string][]TongueTwister, profile *UserPerformance, round, totalRounds, focusArea int) TongueTwister {
+// selectOptimalTwister выбирает оптимальную скороговорку для текущего этапа тренировки.
+// В адаптивном режиме после низкой оценки предпочитаются скороговорки на самую слабую группу звуков
+func selectOptimalTwister(categories map[string][]TongueTwister, profile *UserPerformance, round, totalRounds, focusArea int, adaptive bool) (TongueTwister, error) {
 	// Определяем прогресс тренировки (от 0.0 до 1.0)
-	progress := float64(round-1) / float64(totalRounds-1)
+	progress := 0.0
+	if totalRounds > 1 {
+		progress = float64(round-1) / float64(totalRounds-1)
+	}
 	
 	// Выбираем категорию в зависимости от прогресса и фокуса
 	var category string
@@ -924,23 +4828,82 @@ func selectOptimalTwister(categories map[string][]TongueTwister, profile *UserPe
 		}
 	}
 	
-	// Если категория существует, выбираем случайную скороговорку из нее
-	if len(candidateTwisters) > 0 {
-		// Получаем случайный индекс
-		index := rand.Intn(len(candidateTwisters))
-		return candidateTwisters[index]
+	// После неудачной попытки смещаем выбор к звукам, которые даются хуже всего
+	if adaptive && lastScoreWasPoor(profile) {
+		if group := weakestGroup(profile); group != "" {
+			if focused := twistersWithSoundGroup(candidateTwisters, group); len(focused) > 0 {
+				fmt.Printf("Последняя оценка низкая — подбираем скороговорку на %s звуки\n", group)
+				candidateTwisters = focused
+			}
+		}
+	}
+	
+	// Если категория существует, выбираем случайную скороговорку из нее (с -no-repeat — ещё не показанную)
+	if twister, ok := sessionSampler.Pick(candidateTwisters); ok {
+		return twister, nil
 	}
 	
 	// Запасной вариант - если нет подходящих скороговорок
 	fmt.Println("ПРЕДУПРЕЖДЕНИЕ: Не найдено подходящих скороговорок, используется первая доступная")
 	for _, twisters := range categories {
 		if len(twisters) > 0 {
-			return twisters[0]
+			return twisters[0], nil
 		}
 	}
 	
-	// Если вообще нет скороговорок (что странно, так как массив не должен быть пустым)
-	panic("Ошибка: нет доступных скороговорок для тренировки")
+	// Если вообще нет скороговорок
+	return TongueTwister{}, errors.New("нет доступных скороговорок для тренировки")
+}
+
+// lastScoreWasPoor сообщает, была ли последняя оценка в профиле низкой (2 и ниже)
+func lastScoreWasPoor(profile *UserPerformance) bool {
+	if profile == nil || len(profile.LastScores) == 0 {
+		return false
+	}
+	return profile.LastScores[len(profile.LastScores)-1] <= 2
+}
+
+// weakestGroup возвращает группу звуков с наименьшей успешностью в профиле
+// или пустую строку, если успешность ещё не отслеживалась
+func weakestGroup(profile *UserPerformance) string {
+	if profile == nil {
+		return ""
+	}
+	weakest := ""
+	weakestRate := 0.0
+	// Обходим группы в фиксированном порядке, чтобы при равной успешности выбор был стабильным
+	for _, group := range articulationSoundGroups {
+		rate, ok := profile.SuccessRate[group.Name]
+		if !ok {
+			continue
+		}
+		if weakest == "" || rate < weakestRate {
+			weakest = group.Name
+			weakestRate = rate
+		}
+	}
+	return weakest
+}
+
+// twistersWithSoundGroup оставляет скороговорки, в которых встречаются звуки указанной группы
+func twistersWithSoundGroup(twisters []TongueTwister, group string) []TongueTwister {
+	var sounds []rune
+	for _, g := range articulationSoundGroups {
+		if g.Name == group {
+			sounds = g.Sounds
+			break
+		}
+	}
+	if len(sounds) == 0 {
+		return nil
+	}
+	var result []TongueTwister
+	for _, twister := range twisters {
+		if containsAny(strings.ToLower(twister.Text), sounds) {
+			result = append(result, twister)
+		}
+	}
+	return result
 }
 
 // randomChoice выбирает случайный элемент из списка
@@ -953,11 +4916,24 @@ func randomChoice(options []string) string {
 
 // presentTwisterFeatures отображает специфические особенности скороговорки
 func presentTwisterFeatures(twister TongueTwister, focusArea int) {
+	if factor := dominantDifficultyFactor(twister.Stats); factor != "" {
+		fmt.Printf("Основная сложность: %s\n", factor)
+	}
+	
 	switch focusArea {
 	case 0: // Артикуляция
 		fmt.Printf("Сложные звуки: ")
 		printComplexSounds(twister.Text)
 		fmt.Printf("Сложные сочетания: %d\n", twister.Stats.DifficultCombos)
+		fmt.Printf("Самая длинная цепочка согласных: %d\n", twister.Stats.MaxConsonantRun)
+		for _, group := range articulationSoundGroups {
+			if words := wordsContaining(twister.Text, group.Sounds); len(words) > 0 {
+				fmt.Printf("Слова, где есть %s: %s\n", group.Name, strings.Join(words, ", "))
+			}
+		}
+		if words := repeatedWords(twister.Text); len(words) > 0 {
+			fmt.Printf("Повторяющиеся слова: %s\n", strings.Join(words, ", "))
+		}
 	case 1: // Ритм
 		fmt.Printf("Ритмическая структура: ")
 		printRhythmicStructure(twister.Text)
@@ -980,6 +4956,33 @@ func presentTwisterFeatures(twister TongueTwister, focusArea int) {
 	}
 }
 
+// articulationSoundGroups — группы звуков, успешность по которым отслеживается в профиле
+var articulationSoundGroups = []struct {
+	Name   string
+	Sounds []rune
+}{
+	{Name: "шипящие", Sounds: []rune{'ш', 'щ', 'ж', 'ч'}},
+	{Name: "свистящие", Sounds: []rune{'с', 'з', 'ц'}},
+	{Name: "сонорные", Sounds: []rune{'р', 'л'}},
+}
+
+// wordsContaining возвращает слова текста, в которых есть хотя бы один из звуков,
+// без знаков препинания, без повторов и в порядке появления
+func wordsContaining(text string, sounds []rune) []string {
+	var words []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Fields(text) {
+		word := strings.TrimFunc(field, func(r rune) bool { return !unicode.IsLetter(r) })
+		lower := strings.ToLower(word)
+		if word == "" || seen[lower] || !containsAny(lower, sounds) {
+			continue
+		}
+		seen[lower] = true
+		words = append(words, word)
+	}
+	return words
+}
+
 // countSyllables подсчитывает количество слогов в тексте
 func countSyllables(text string) int {
 	words := strings.Fields(text)
@@ -994,7 +4997,7 @@ func countSyllables(text string) int {
 
 // provideFocusedAdvice дает конкретные советы по работе над этой скороговоркой
 func provideFocusedAdvice(twister TongueTwister, focusArea int, round int, difficulty float64) {
-	fmt.Println("Фокус раунда:")
+	printGuidance("Фокус раунда:")
 	
 	switch focusArea {
 	case 0: // Артикуляция
@@ -1005,25 +5008,25 @@ func provideFocusedAdvice(twister TongueTwister, focusArea int, round int, diffi
 		suggestRhythmFocus(twister.Text, round)
 		// Дополнительный совет по ритму
 		if twister.Stats.WordCount > 5 {
-			fmt.Println("Следите за равномерностью произношения длинной фразы")
+			printGuidance("Следите за равномерностью произношения длинной фразы")
 		} else {
-			fmt.Println("Сконцентрируйтесь на ровном ритме коротких слов")
+			printGuidance("Сконцентрируйтесь на ровном ритме коротких слов")
 		}
 	case 2: // Ударения
-		fmt.Printf("Правильные ударения в словах (уровень %.1f)\n", difficulty)
+		printGuidance("Правильные ударения в словах (уровень %.1f)", difficulty)
 		// Указываем, на какие слова особенно обратить внимание
 		highlightStressPatterns(twister.Text)
 	case 3: // Дыхание
 		suggestBreathingPattern(twister.Text, round)
 		// Дополнительный совет по дыханию
 		if twister.Stats.CharCount > 50 {
-			fmt.Println("Делайте глубокий вдох перед началом этой длинной фразы")
+			printGuidance("Делайте глубокий вдох перед началом этой длинной фразы")
 		}
 	case 4: // Скорость
 		suggestSpeedFocus(twister.Stats.WordCount, round, round+2)
 		// Дополнительный совет по скорости
 		if twister.Stats.DifficultCombos > 2 {
-			fmt.Println("Особое внимание уделите сложным сочетаниям звуков")
+			printGuidance("Особое внимание уделите сложным сочетаниям звуков")
 		}
 	}
 }
@@ -1058,19 +5061,50 @@ func highlightDifficultSounds(text string) {
 		}
 	}
 	
-	// Если нашли сложные звуки, выводим рекомендации
+	// Если нашли сложные звуки, выводим рекомендации и подсказки по артикуляции
 	if len(foundGroups) > 0 {
 		fmt.Println("\nСложные звуковые группы в этой скороговорке:")
 		for group, count := range foundGroups {
 			fmt.Printf("- %s (%d звуков)\n", group, count)
+			for _, sound := range difficultGroups[group] {
+				if hint := articulationHint(sound); hint != "" && strings.ContainsRune(text, sound) {
+					fmt.Printf("    %c: %s\n", sound, hint)
+				}
+			}
 		}
 	}
 }
 
+// articulationHints содержит краткие подсказки о положении губ и языка для сложных звуков
+var articulationHints = map[rune]string{
+	'ж': "губы округлены и выдвинуты, широкий язык поднят к нёбу, звук звонкий",
+	'ш': "губы округлены и выдвинуты, широкий язык поднят к нёбу, звук глухой",
+	'щ': "долгий мягкий звук: средняя часть языка поднята к нёбу, губы слегка вытянуты",
+	'ч': "кончик языка смыкается с нёбом за верхними зубами и мягко размыкается",
+	'с': "кончик языка у нижних зубов, воздух идёт узкой струёй по середине языка",
+	'з': "как «с», но с голосом",
+	'ц': "слитные «т» и «с»: кончик языка у нижних зубов, спинка смыкается с верхними",
+	'р': "кончик языка вибрирует у бугорков за верхними зубами, язык не напряжён",
+	'л': "кончик языка прижат к верхним зубам, воздух проходит по бокам языка",
+	'п': "губы сомкнуты и резко размыкаются без голоса",
+	'б': "губы сомкнуты и резко размыкаются с голосом",
+	'т': "кончик языка у верхних зубов, короткий отрыв без голоса",
+	'д': "кончик языка у верхних зубов, короткий отрыв с голосом",
+	'к': "задняя часть языка смыкается с нёбом и резко отрывается",
+	'г': "как «к», но с голосом",
+	'ф': "нижняя губа легко касается верхних зубов, воздух проходит с трением",
+	'х': "задняя часть языка приближается к нёбу, воздух проходит с трением",
+}
+
+// articulationHint возвращает подсказку по артикуляции звука или пустую строку, если её нет
+func articulationHint(sound rune) string {
+	return articulationHints[unicode.ToLower(sound)]
+}
+
 // updateUserPerformance обновляет статистику пользователя
 func updateUserPerformance(profile *UserPerformance, twister TongueTwister, score int, focusArea int) {
 	// Обновляем успешность по типам звуков
-	difficulty := getDifficultyLevel(twister.Score)
+	difficulty := twisterLevel(twister)
 	
 	// Инициализируем значение, если его ещё нет
 	if _, exists := profile.DifficultyRating[difficulty]; !exists {
@@ -1107,12 +5141,28 @@ func updateUserPerformance(profile *UserPerformance, twister TongueTwister, scor
 		}
 	}
 	
-	// Обновляем средний балл
-	totalScore := 0
-	for _, s := range profile.LastScores {
-		totalScore += s
+	// Обновляем средний балл, придавая свежим оценкам больший вес
+	profile.AverageScore = weightedAverageScore(profile.LastScores, profile.ScoreDecay)
+}
+
+// weightedAverageScore возвращает экспоненциально взвешенное среднее оценок:
+// самая свежая оценка имеет вес 1, предыдущая — decay, ещё более ранняя — decay² и т.д.
+// При decay = 1 получается обычное среднее арифметическое.
+func weightedAverageScore(scores []int, decay float64) float64 {
+	if len(scores) == 0 {
+		return 0
 	}
-	profile.AverageScore = float64(totalScore) / float64(len(profile.LastScores))
+	
+	weight := 1.0
+	weightedSum := 0.0
+	totalWeight := 0.0
+	for i := len(scores) - 1; i >= 0; i-- {
+		weightedSum += float64(scores[i]) * weight
+		totalWeight += weight
+		weight *= decay
+	}
+	
+	return weightedSum / totalWeight
 }
 
 // adjustDifficulties корректирует сложность последующих раундов в зависимости от успешности
@@ -1154,17 +5204,17 @@ func provideFeedback(score int, twister TongueTwister, focusArea int) {
 	// Общая обратная связь по оценке
 	switch score {
 	case 1, 2:
-		fmt.Println("Не расстраивайтесь, эта скороговорка действительно непростая!")
-		fmt.Println("Попробуйте разбить ее на маленькие части и проговорить медленнее.")
+		printGuidance("Не расстраивайтесь, эта скороговорка действительно непростая!")
+		printGuidance("Попробуйте разбить ее на маленькие части и проговорить медленнее.")
 	case 3:
-		fmt.Println("Неплохо! Продолжайте работать над дикцией.")
-		fmt.Println("Обратите внимание на правильное положение языка и губ.")
+		printGuidance("Неплохо! Продолжайте работать над дикцией.")
+		printGuidance("Обратите внимание на правильное положение языка и губ.")
 	case 4:
-		fmt.Println("Хорошо! Вы почти достигли совершенства.")
-		fmt.Println("Попробуйте слегка увеличить скорость произношения.")
+		printGuidance("Хорошо! Вы почти достигли совершенства.")
+		printGuidance("Попробуйте слегка увеличить скорость произношения.")
 	case 5:
-		fmt.Println("Отлично! Идеальное произношение!")
-		fmt.Printf("Скороговорка \"%s\" сложности полностью освоена.\n", getDifficultyLevel(twister.Score))
+		printGuidance("Отлично! Идеальное произношение!")
+		printGuidance("Скороговорка \"%s\" сложности полностью освоена.", twisterLevel(twister))
 	}
 	
 	// Дополнительная обратная связь в зависимости от фокуса
@@ -1172,26 +5222,27 @@ func provideFeedback(score int, twister TongueTwister, focusArea int) {
 		switch focusArea {
 		case 0: // Артикуляция
 			if twister.Stats.DifficultSounds > 0 {
-				fmt.Println("▶ Совет: Уделите особое внимание чёткому произношению сложных звуков.")
+				printGuidance("▶ Совет: Уделите особое внимание чёткому произношению сложных звуков.")
 			}
 		case 1: // Ритм
-			fmt.Println("▶ Совет: Попробуйте прохлопать ритм скороговорки перед произнесением.")
+			printGuidance("▶ Совет: Попробуйте прохлопать ритм скороговорки перед произнесением.")
 		case 2: // Ударения
-			fmt.Println("▶ Совет: Произнесите скороговорку медленно, выделяя ударные слоги.")
+			printGuidance("▶ Совет: Произнесите скороговорку медленно, выделяя ударные слоги.")
 		case 3: // Дыхание
-			fmt.Println("▶ Совет: Сделайте несколько глубоких вдохов перед произнесением.")
+			printGuidance("▶ Совет: Сделайте несколько глубоких вдохов перед произнесением.")
 		case 4: // Скорость
-			fmt.Println("▶ Совет: Начните очень медленно и постепенно ускоряйтесь.")
+			printGuidance("▶ Совет: Начните очень медленно и постепенно ускоряйтесь.")
 		}
 	}
 }
 
 // analyzeTrainingResults анализирует результаты тренировки и дает рекомендации
-func analyzeTrainingResults(profile *UserPerformance, totalScore, totalRounds, focusArea int) {
+func analyzeTrainingResults(profile *UserPerformance, totalScore, totalRounds, focusArea int, rounds []RoundResult) {
 	avgScore := float64(totalScore) / float64(totalRounds)
 	
 	fmt.Println("=== Анализ результатов тренировки ===")
 	fmt.Printf("Ваш средний балл: %.1f из 5.0\n", avgScore)
+	fmt.Printf("С учётом последних раундов: %.1f из 5.0\n", profile.AverageScore)
 	
 	// Общий анализ
 	if avgScore < 3.0 {
@@ -1225,6 +5276,9 @@ func analyzeTrainingResults(profile *UserPerformance, totalScore, totalRounds, f
 		}
 	}
 	
+	// Какие сложные сочетания встретились за сессию
+	printSessionCombos(rounds)
+	
 	// Дополнительный совет в зависимости от фокуса
 	switch focusArea {
 	case 0: // Артикуляция
@@ -1244,27 +5298,56 @@ func analyzeTrainingResults(profile *UserPerformance, totalScore, totalRounds, f
 	suggestNextTrainingFocus(focusArea, avgScore)
 }
 
+// printSessionCombos выводит сложные сочетания, которые встретились в скороговорках сессии
+func printSessionCombos(rounds []RoundResult) {
+	combos := make(map[string]int)
+	for _, round := range rounds {
+		for combo, count := range comboOccurrences(round.Twister.Text) {
+			combos[combo] += count
+		}
+	}
+	if len(combos) == 0 {
+		return
+	}
+	
+	names := make([]string, 0, len(combos))
+	for combo := range combos {
+		names = append(names, combo)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if combos[names[i]] != combos[names[j]] {
+			return combos[names[i]] > combos[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	
+	fmt.Println("• Отработанные сложные сочетания:")
+	for _, combo := range names {
+		fmt.Printf("  «%s»: %d\n", combo, combos[combo])
+	}
+}
+
 // suggestArticulationFocus provides specific guidance for articulation practice
 func suggestArticulationFocus(text string, round int) {
 	text = strings.ToLower(text)
 	
 	switch round {
 	case 1:
-		fmt.Println("Фокус: чёткое произношение всех согласных")
+		printGuidance("Фокус: чёткое произношение всех согласных")
 	case 2:
-		fmt.Println("Фокус: выделение шипящих и свистящих звуков (ш, щ, ж, с, з)")
+		printGuidance("Фокус: выделение шипящих и свистящих звуков (ш, щ, ж, с, з)")
 	case 3:
-		fmt.Println("Фокус: проработка сочетаний согласных")
+		printGuidance("Фокус: проработка сочетаний согласных")
 	case 4:
-		fmt.Println("Фокус: плавные переходы между всеми звуками")
+		printGuidance("Фокус: плавные переходы между всеми звуками")
 	default:
-		fmt.Println("Фокус: идеальное произношение всех звуков")
+		printGuidance("Фокус: идеальное произношение всех звуков")
 	}
 	
 	// Находим сложные группы звуков для выделения
 	for _, combo := range difficultCombinations {
 		if strings.Contains(text, combo) {
-			fmt.Printf("Обратите особое внимание на сочетание \"%s\"\n", combo)
+			printGuidance("Обратите особое внимание на сочетание \"%s\"", combo)
 			break
 		}
 	}
@@ -1276,13 +5359,13 @@ func suggestRhythmFocus(text string, round int) {
 	
 	switch round {
 	case 1:
-		fmt.Println("Фокус: равномерное произношение каждого слога")
+		printGuidance("Фокус: равномерное произношение каждого слога")
 	case 2:
-		fmt.Println("Фокус: правильные паузы между словами")
+		printGuidance("Фокус: правильные паузы между словами")
 	case 3:
-		fmt.Println("Фокус: плавный ритмический рисунок")
+		printGuidance("Фокус: плавный ритмический рисунок")
 	default:
-		fmt.Println("Фокус: естественный ритм с сохранением четкости")
+		printGuidance("Фокус: естественный ритм с сохранением четкости")
 	}
 	
 	// Показываем ритмическую структуру с выделением ударений
@@ -1330,13 +5413,13 @@ func suggestBreathingPattern(text string, round int) {
 	
 	switch round {
 	case 1:
-		fmt.Println("Фокус: глубокий вдох перед началом")
+		printGuidance("Фокус: глубокий вдох перед началом")
 	case 2:
-		fmt.Println("Фокус: произнесите на одном дыхании")
+		printGuidance("Фокус: произнесите на одном дыхании")
 	case 3:
-		fmt.Println("Фокус: контроль интенсивности выдоха")
+		printGuidance("Фокус: контроль интенсивности выдоха")
 	default:
-		fmt.Println("Фокус: плавное распределение дыхания")
+		printGuidance("Фокус: плавное распределение дыхания")
 	}
 	
 	// Рекомендуем места для вдоха при длинных фразах
@@ -1354,6 +5437,50 @@ func suggestBreathingPattern(text string, round int) {
 	}
 }
 
+// splitClauses splits text into clauses on punctuation, keeping each punctuation mark with its clause
+func splitClauses(text string) []string {
+	var clauses []string
+	var current strings.Builder
+	
+	flush := func() {
+		if clause := strings.TrimSpace(current.String()); clause != "" {
+			clauses = append(clauses, clause)
+		}
+		current.Reset()
+	}
+	
+	for _, char := range text {
+		if char == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(char)
+		if strings.ContainsRune(",.;:!?…—", char) {
+			flush()
+		}
+	}
+	flush()
+	
+	return clauses
+}
+
+// revealClauses shows a long phrase one clause at a time so the breath can be built up gradually
+func revealClauses(text string) {
+	clauses := splitClauses(text)
+	if len(clauses) < 2 {
+		return
+	}
+	
+	fmt.Println("\nПостепенное раскрытие фразы: произносите на одном дыхании всё, что уже открыто.")
+	for i := range clauses {
+		fmt.Println()
+		fmt.Println(strings.Join(clauses[:i+1], " "))
+		fmt.Printf("Часть %d из %d. Нажмите Enter, чтобы открыть следующую...\n", i+1, len(clauses))
+		waitForEnter()
+	}
+	fmt.Println("Теперь вся фраза целиком:")
+}
+
 // suggestSpeedFocus provides guidance for speed training
 func suggestSpeedFocus(wordCount int, round, totalRounds int) {
 	// Расчет рекомендуемого темпа в словах в минуту
@@ -1363,23 +5490,23 @@ func suggestSpeedFocus(wordCount int, round, totalRounds int) {
 	speedMultiplier := float64(round) / float64(totalRounds)
 	targetSpeed := baseSpeed + int(float64(maxSpeed-baseSpeed)*speedMultiplier)
 	
-	fmt.Printf("Рекомендуемая скорость: примерно %d слов в минуту\n", targetSpeed)
+	printGuidance("Рекомендуемая скорость: примерно %d слов в минуту", targetSpeed)
 	
 	// Рекомендации по технике для текущего раунда
 	switch round {
 	case 1:
-		fmt.Println("Фокус: четкое произношение в медленном темпе")
+		printGuidance("Фокус: четкое произношение в медленном темпе")
 	case 2:
-		fmt.Println("Фокус: постепенное увеличение темпа")
+		printGuidance("Фокус: постепенное увеличение темпа")
 	case 3:
-		fmt.Println("Фокус: плавность и скорость")
+		printGuidance("Фокус: плавность и скорость")
 	default:
-		fmt.Println("Фокус: максимальная скорость с сохранением четкости")
+		printGuidance("Фокус: максимальная скорость с сохранением четкости")
 	}
 	
 	// Рассчитываем примерный интервал времени
 	seconds := float64(wordCount) / (float64(targetSpeed) / 60.0)
-	fmt.Printf("Целевое время: около %.1f секунд\n", seconds)
+	printGuidance("Целевое время: около %.1f секунд", seconds)
 }
 
 // suggestNextTrainingFocus recommends the next training focus based on current results
@@ -1587,8 +5714,63 @@ func countRussianSyllables(word string) int {
 	return count
 }
 
+// needsRemedialExercise сообщает, были ли две последние оценки низкими (2 и ниже)
+func needsRemedialExercise(scores []int) bool {
+	if len(scores) < 2 {
+		return false
+	}
+	return scores[len(scores)-1] <= 2 && scores[len(scores)-2] <= 2
+}
+
+// splitRussianSyllables splits a Russian word into syllables using the open-syllable rule:
+// consonants between vowels start the next syllable, while й, ь and ъ stay with the previous one
+func splitRussianSyllables(word string) []string {
+	runes := []rune(word)
+	lower := []rune(strings.ToLower(word))
+	
+	var vowels []int
+	for i, char := range lower {
+		if isRussianVowel(char) {
+			vowels = append(vowels, i)
+		}
+	}
+	if len(vowels) < 2 {
+		return []string{word}
+	}
+	
+	var syllables []string
+	start := 0
+	for v := 0; v < len(vowels)-1; v++ {
+		end := vowels[v] + 1
+		for end < vowels[v+1] && (lower[end] == 'й' || lower[end] == 'ь' || lower[end] == 'ъ') {
+			end++
+		}
+		syllables = append(syllables, string(runes[start:end]))
+		start = end
+	}
+	syllables = append(syllables, string(runes[start:]))
+	
+	return syllables
+}
+
+// runSyllableExercise предлагает медленно проговорить скороговорку по слогам
+func runSyllableExercise(text string) {
+	fmt.Println("\n▶ Дополнительное упражнение: разбейте скороговорку на слоги")
+	fmt.Println("Последние оценки были низкими, поэтому сначала проговорите текст медленно, по слогам:")
+	
+	parts := []string{}
+	for _, word := range strings.Fields(text) {
+		parts = append(parts, strings.Join(splitRussianSyllables(word), "-"))
+	}
+	fmt.Println(strings.Join(parts, " "))
+	fmt.Printf("Всего слогов: %d. Отчётливо произносите каждый слог.\n", countSyllables(text))
+	
+	fmt.Println("Нажмите Enter, когда закончите упражнение...")
+	waitForEnter()
+}
+
 // selectBalancedTwisters selects twisters from different difficulty levels
-func selectBalancedTwisters(easy, medium, hard, expert []TongueTwister, totalCount int) []TongueTwister {
+func selectBalancedTwisters(easy, medium, hard, expert []TongueTwister, totalCount int, spread bool) []TongueTwister {
 	result := []TongueTwister{}
 	
 	// Calculate how many from each category to take
@@ -1657,26 +5839,37 @@ func selectBalancedTwisters(easy, medium, hard, expert []TongueTwister, totalCou
 	expertCount = min(expertCount, len(expert))
 	
 	// Select from each category
+	var easyPicks, mediumPicks, hardPicks, expertPicks []TongueTwister
 	if easyCount > 0 {
-		result = append(result, selectRandomTwisters(easy, easyCount)...)
+		easyPicks = selectRandomTwisters(easy, easyCount)
 		fmt.Printf("Выбрано %d легких скороговорок\n", easyCount)
 	}
 	
 	if mediumCount > 0 {
-		result = append(result, selectRandomTwisters(medium, mediumCount)...)
+		mediumPicks = selectRandomTwisters(medium, mediumCount)
 		fmt.Printf("Выбрано %d средних скороговорок\n", mediumCount)
 	}
 	
 	if hardCount > 0 {
-		result = append(result, selectRandomTwisters(hard, hardCount)...)
+		hardPicks = selectRandomTwisters(hard, hardCount)
 		fmt.Printf("Выбрано %d сложных скороговорок\n", hardCount)
 	}
 	
 	if expertCount > 0 {
-		result = append(result, selectRandomTwisters(expert, expertCount)...)
+		expertPicks = selectRandomTwisters(expert, expertCount)
 		fmt.Printf("Выбрано %d очень сложных скороговорок\n", expertCount)
 	}
 	
+	// Alternate difficulties instead of leaving their order to chance
+	if spread {
+		return interleaveByDifficulty(easyPicks, mediumPicks, hardPicks, expertPicks)
+	}
+	
+	result = append(result, easyPicks...)
+	result = append(result, mediumPicks...)
+	result = append(result, hardPicks...)
+	result = append(result, expertPicks...)
+	
 	// Shuffle the final selection to mix difficulties
 	shuffled := make([]TongueTwister, len(result))
 	copy(shuffled, result)
@@ -1690,10 +5883,447 @@ func selectBalancedTwisters(easy, medium, hard, expert []TongueTwister, totalCou
 	return shuffled
 }
 
+// shuffleWithinLevels shuffles each contiguous run of twisters with the same difficulty level
+// in place, so a list sorted by level keeps its easy-to-hard order between levels
+func shuffleWithinLevels(twisters []TongueTwister) {
+	for start := 0; start < len(twisters); {
+		level := twisterLevel(twisters[start])
+		end := start + 1
+		for end < len(twisters) && twisterLevel(twisters[end]) == level {
+			end++
+		}
+		
+		run := twisters[start:end]
+		rand.Shuffle(len(run), func(i, j int) {
+			run[i], run[j] = run[j], run[i]
+		})
+		start = end
+	}
+}
+
+// interleaveByDifficulty merges per-difficulty picks so that neighbouring twisters come from
+// different buckets whenever possible. At each step it takes the next twister from the bucket
+// with the most twisters left, skipping the bucket used last; adjacent repeats only remain when
+// one bucket holds more than half of all picks.
+func interleaveByDifficulty(buckets ...[]TongueTwister) []TongueTwister {
+	total := 0
+	for _, bucket := range buckets {
+		total += len(bucket)
+	}
+	
+	result := make([]TongueTwister, 0, total)
+	next := make([]int, len(buckets))
+	last := -1
+	for len(result) < total {
+		best := -1
+		for i, bucket := range buckets {
+			remaining := len(bucket) - next[i]
+			if remaining == 0 || (i == last && remaining < total-len(result)) {
+				continue
+			}
+			if best == -1 || remaining > len(buckets[best])-next[best] {
+				best = i
+			}
+		}
+		result = append(result, buckets[best][next[best]])
+		next[best]++
+		last = best
+	}
+	
+	return result
+}
+
 // min returns the smaller of two integers
 func min(a, b int) int {
 	if a < b {
 		return a
 	}
 	return b
-} 
\ No newline at end of file
+} 
+// twisterPage is a single page of the /twisters listing
+type twisterPage struct {
+	Page     int             `json:"page"`
+	PerPage  int             `json:"per_page"`
+	Total    int             `json:"total"`
+	Twisters []TongueTwister `json:"twisters"`
+}
+
+// corpusSummary is the /stats response describing the loaded corpus
+type corpusSummary struct {
+	Total        int            `json:"total"`
+	Levels       map[string]int `json:"levels"`
+	AverageScore float64        `json:"average_score"`
+	MinScore     float64        `json:"min_score"`
+	MaxScore     float64        `json:"max_score"`
+}
+
+// pageBounds returns the slice bounds of a 1-based page of perPage items out of total.
+// Pages past the end are empty; the page number is checked before multiplying, so a huge
+// page cannot overflow into a negative offset.
+func pageBounds(page, perPage, total int) (start, end int) {
+	if page-1 >= (total+perPage-1)/perPage {
+		return total, total
+	}
+	start = (page - 1) * perPage
+	return start, min(start+perPage, total)
+}
+
+// runServer serves the analyzed twisters read-only over HTTP until SIGINT or SIGTERM is received
+func runServer(addr string, twisters []TongueTwister) error {
+	mux := http.NewServeMux()
+	
+	mux.HandleFunc("/twisters", readOnly(func(w http.ResponseWriter, r *http.Request) {
+		page, err := queryInt(r, "page", 1)
+		if err != nil || page < 1 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+		perPage, err := queryInt(r, "per_page", 20)
+		if err != nil || perPage < 1 || perPage > 100 {
+			http.Error(w, "invalid per_page (1-100)", http.StatusBadRequest)
+			return
+		}
+		
+		start, end := pageBounds(page, perPage, len(twisters))
+		writeJSON(w, twisterPage{
+			Page:     page,
+			PerPage:  perPage,
+			Total:    len(twisters),
+			Twisters: twisters[start:end],
+		})
+	}))
+	
+	mux.HandleFunc("/twisters/random", readOnly(func(w http.ResponseWriter, r *http.Request) {
+		candidates := twisters
+		if name := r.URL.Query().Get("difficulty"); name != "" && name != "all" {
+			level, ok := difficultyLevelByName(name)
+			if !ok {
+				http.Error(w, "unknown difficulty (easy, medium, hard, expert, all)", http.StatusBadRequest)
+				return
+			}
+			candidates = filterTwistersByDifficulty(twisters, level)
+		}
+		if len(candidates) == 0 {
+			http.Error(w, "no twisters of this difficulty", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, candidates[rand.Intn(len(candidates))])
+	}))
+	
+	mux.HandleFunc("/stats", readOnly(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, summarizeCorpus(twisters))
+	}))
+	
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	
+	// Shut down gracefully on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	fmt.Printf("HTTP-сервер запущен на %s (Ctrl-C для остановки)\n", addr)
+	
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	
+	fmt.Println("\nОстанавливаем HTTP-сервер...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// summarizeCorpus computes the per-level counts and score range of the corpus
+func summarizeCorpus(twisters []TongueTwister) corpusSummary {
+	summary := corpusSummary{
+		Total:  len(twisters),
+		Levels: map[string]int{Easy: 0, Medium: 0, Hard: 0, Expert: 0},
+	}
+	if len(twisters) == 0 {
+		return summary
+	}
+	
+	summary.MinScore = twisters[0].Score
+	summary.MaxScore = twisters[0].Score
+	total := 0.0
+	for _, twister := range twisters {
+		summary.Levels[twisterLevel(twister)]++
+		total += twister.Score
+		summary.MinScore = math.Min(summary.MinScore, twister.Score)
+		summary.MaxScore = math.Max(summary.MaxScore, twister.Score)
+	}
+	summary.AverageScore = total / float64(len(twisters))
+	
+	return summary
+}
+
+// queryInt reads an integer query parameter, returning def when it is absent
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// readOnly rejects every method except GET and HEAD with 405 Method Not Allowed
+func readOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// writeJSON encodes v as the JSON response body. Part of the body may already be sent
+// when encoding fails, so the error is only logged.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка записи ответа: %v\n", err)
+	}
+}
+
+// compareProfiles печатает два профиля рядом; в каждой строке большее значение отмечено звёздочкой
+func compareProfiles(nameA string, a *UserPerformance, nameB string, b *UserPerformance) {
+	printComparisonLine("Показатель", nameA, nameB)
+	printComparisonRow("Средний балл", 2, a.AverageScore, true, b.AverageScore, true)
+	printComparisonRow("Сессий", 0, float64(a.TotalSessions), true, float64(b.TotalSessions), true)
+	
+	// Группы звуков могут быть только в одном из профилей
+	groups := make(map[string]bool)
+	for group := range a.SuccessRate {
+		groups[group] = true
+	}
+	for group := range b.SuccessRate {
+		groups[group] = true
+	}
+	names := make([]string, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	
+	for _, group := range names {
+		rateA, okA := a.SuccessRate[group]
+		rateB, okB := b.SuccessRate[group]
+		printComparisonRow("Успешность: "+group, 2, rateA, okA, rateB, okB)
+	}
+	fmt.Println("* — большее значение в строке, «—» — нет данных")
+}
+
+// printComparisonRow печатает строку сравнения с заданным числом знаков после запятой;
+// отсутствующее значение выводится как «—»
+func printComparisonRow(label string, decimals int, a float64, okA bool, b float64, okB bool) {
+	cell := func(value float64, ok, larger bool) string {
+		if !ok {
+			return "—"
+		}
+		text := strconv.FormatFloat(value, 'f', decimals, 64)
+		if larger {
+			text += "*"
+		}
+		return text
+	}
+	printComparisonLine(label,
+		cell(a, okA, okA && (!okB || a > b)),
+		cell(b, okB, okB && (!okA || b > a)))
+}
+
+// printComparisonLine выравнивает колонки по числу символов, а не байтов, чтобы кириллица не сбивала таблицу
+func printComparisonLine(label, a, b string) {
+	padding := func(text string, width int) string {
+		if n := len([]rune(text)); n < width {
+			return strings.Repeat(" ", width-n)
+		}
+		return ""
+	}
+	fmt.Printf("%s%s %s%s %s%s\n", label, padding(label, 28), padding(a, 16), a, padding(b, 16), b)
+}
+
+// PlanDay — один день недельного плана тренировок
+type PlanDay struct {
+	Day        int
+	Mode       string
+	FocusArea  int
+	Difficulty string // Имя сложности для -difficulty (easy, medium, hard, expert)
+	Level      int
+}
+
+// difficultyNames перечисляет имена сложностей для -difficulty от лёгкой к самой сложной
+var difficultyNames = []string{"easy", "medium", "hard", "expert"}
+
+// generatePlan составляет план на 7 дней: чаще всего отрабатывается самый слабый аспект,
+// сложность чередуется между освоенной и следующей за ней, а последний день — испытание
+func generatePlan(profile *UserPerformance) []PlanDay {
+	// Освоенная сложность — самая высокая, по которой средняя оценка не ниже 3.5
+	comfortable := 0
+	for i, name := range difficultyNames {
+		level, _ := difficultyLevelByName(name)
+		if rating, ok := profile.DifficultyRating[level]; ok && rating >= 3.5 {
+			comfortable = i
+		}
+	}
+	stretch := min(comfortable+1, len(difficultyNames)-1)
+	
+	// Уровень требований растёт вместе со средним баллом
+	level := int(math.Round(profile.AverageScore))
+	if level < 1 {
+		level = 1
+	} else if level > 5 {
+		level = 5
+	}
+	
+	// Если есть проблемная группа звуков, артикуляция идёт первой и повторяется
+	rotation := []int{0, 1, 2, 3, 4, 0}
+	if weakestRate, ok := weakestSuccessRate(profile); !ok || weakestRate >= 3.5 {
+		rotation = []int{1, 0, 2, 3, 4, 1}
+	}
+	
+	plan := make([]PlanDay, 0, 7)
+	for i, focusArea := range rotation {
+		difficulty := difficultyNames[comfortable]
+		if i%2 == 1 {
+			difficulty = difficultyNames[stretch]
+		}
+		plan = append(plan, PlanDay{
+			Day:        i + 1,
+			Mode:       PerfectionMode,
+			FocusArea:  focusArea,
+			Difficulty: difficulty,
+			Level:      level,
+		})
+	}
+	plan = append(plan, PlanDay{
+		Day:        7,
+		Mode:       ChallengeMode,
+		Difficulty: difficultyNames[stretch],
+		Level:      level,
+	})
+	
+	return plan
+}
+
+// weakestSuccessRate возвращает наименьшую успешность по группам звуков
+func weakestSuccessRate(profile *UserPerformance) (float64, bool) {
+	found := false
+	weakest := 0.0
+	for _, rate := range profile.SuccessRate {
+		if !found || rate < weakest {
+			weakest = rate
+			found = true
+		}
+	}
+	return weakest, found
+}
+
+// printPlan выводит план с готовыми командами для каждого дня
+func printPlan(plan []PlanDay, profilePath string) {
+	fmt.Println("=== План тренировок на неделю ===")
+	for _, day := range plan {
+		command := fmt.Sprintf("./easy_trainer -mode %s -difficulty %s", day.Mode, day.Difficulty)
+		if day.Mode == PerfectionMode {
+			fmt.Printf("День %d: %s, сложность %s, уровень %d\n",
+				day.Day, dictionFocusAreas[day.FocusArea].Name, day.Difficulty, day.Level)
+			command += fmt.Sprintf(" -focus %d -level %d", day.FocusArea, day.Level)
+		} else {
+			fmt.Printf("День %d: испытание, сложность %s\n", day.Day, day.Difficulty)
+		}
+		if profilePath != "" {
+			command += " -profile " + profilePath
+		}
+		fmt.Printf("  %s\n", command)
+	}
+}
+
+// reminderHour — час дня, на который по умолчанию назначается следующая тренировка
+const reminderHour = 19
+
+// reminderCommand — команда, которую запускают строки для cron и at
+const reminderCommand = `notify-send "Скороговорки" "Пора потренировать дикцию"`
+
+// Reminder — предложенное время следующей тренировки и строки для установки напоминания
+type Reminder struct {
+	At     time.Time // Когда тренироваться
+	Streak int       // Текущая серия дней подряд
+	Reason string    // Почему выбрано это время
+	Cron   string    // Ежедневное напоминание для crontab
+	AtLine string    // Разовое напоминание через at
+}
+
+// suggestReminder предлагает время следующей тренировки по датам занятий в профиле:
+// если сегодня уже занимались — завтра в reminderHour, иначе сегодня в reminderHour,
+// а если этот час прошёл — через полчаса, пока день не кончился, чтобы не прервать серию
+func suggestReminder(profile *UserPerformance, now time.Time) Reminder {
+	today := now.Format("2006-01-02")
+	trainedToday := false
+	for _, date := range profile.SessionDates {
+		if date == today {
+			trainedToday = true
+			break
+		}
+	}
+	
+	reminder := Reminder{}
+	todayAt := time.Date(now.Year(), now.Month(), now.Day(), reminderHour, 0, 0, 0, now.Location())
+	switch {
+	case trainedToday:
+		reminder.Streak = dayStreak(profile.SessionDates, now)
+		reminder.At = todayAt.AddDate(0, 0, 1)
+		reminder.Reason = "сегодня вы уже занимались — продолжите серию завтра"
+	default:
+		reminder.Streak = dayStreak(profile.SessionDates, now.AddDate(0, 0, -1))
+		reminder.At = todayAt
+		if reminder.Streak > 0 {
+			reminder.Reason = "вчера вы занимались — потренируйтесь сегодня, чтобы не прервать серию"
+		} else if len(profile.SessionDates) > 0 {
+			reminder.Reason = fmt.Sprintf("последняя тренировка была %s — пора вернуться к занятиям", profile.SessionDates[len(profile.SessionDates)-1])
+		} else {
+			reminder.Reason = "тренировок ещё не было — начните сегодня"
+		}
+		if !todayAt.After(now) {
+			// Назначенный час прошёл: предлагаем ближайшие полчаса, если они ещё сегодня
+			soon := now.Add(30 * time.Minute).Truncate(time.Minute)
+			if soon.Format("2006-01-02") == today {
+				reminder.At = soon
+			} else {
+				reminder.At = todayAt.AddDate(0, 0, 1)
+			}
+		}
+	}
+	
+	// Ежедневное напоминание всегда в обычный час, даже если сегодня предложено другое время
+	reminder.Cron = fmt.Sprintf("0 %d * * * %s", reminderHour, reminderCommand)
+	reminder.AtLine = fmt.Sprintf("echo '%s' | at %s", reminderCommand, reminder.At.Format("15:04 2006-01-02"))
+	return reminder
+}
+
+// printReminder выводит предложенное время и строки для установки напоминания
+func printReminder(reminder Reminder) {
+	fmt.Println("=== Напоминание о тренировке ===")
+	fmt.Printf("Серия: %d дн. подряд\n", reminder.Streak)
+	fmt.Printf("Следующая тренировка: %s (%s)\n", reminder.At.Format("02.01.2006 15:04"), reminder.Reason)
+	fmt.Println()
+	fmt.Println("Ежедневное напоминание (добавьте строку через crontab -e):")
+	fmt.Printf("  %s\n", reminder.Cron)
+	fmt.Println("Разовое напоминание:")
+	fmt.Printf("  %s\n", reminder.AtLine)
+}
@@ -0,0 +1,791 @@
+package main
+
+import (
+	"bytes"
+	"go/build"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCountDifficultCombinationsNoOverlap(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"встреча", 1}, // "встр", not "встр" + "стр"
+		{"ВСТРЕЧА", 1}, // case does not matter
+		{"чувство", 1}, // "вств", not "вств" + "ств"
+		{"строка", 1},  // plain "стр"
+		{"встреча и строка", 2},
+		{"", 0},
+		{"мама", 0},
+	}
+	for _, tt := range tests {
+		if got := countDifficultCombinations(tt.text); got != tt.want {
+			t.Errorf("countDifficultCombinations(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	occurrences := comboOccurrences("встреча")
+	if occurrences["встр"] != 1 || occurrences["стр"] != 0 {
+		t.Errorf("comboOccurrences(\"встреча\") = %v, want only встр once", occurrences)
+	}
+}
+
+func TestTransitionCountAlternatingVsClustered(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"молоко", 5}, // м-о-л-о-к-о alternates at every letter
+		{"взгляд", 2}, // взгл cluster, then я, then д
+		{"ма ма", 2},  // no transition across the space
+		{"ма–ма", 2},  // nor across an en dash
+		{"ма—ма", 2},  // or an em dash
+		{"а", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := transitionCount(tt.text); got != tt.want {
+			t.Errorf("transitionCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	alternating := TongueTwister{Text: "молоко молоко"}
+	clustered := TongueTwister{Text: "взгляд взгляд"}
+	analyzeTwister(&alternating)
+	analyzeTwister(&clustered)
+	if alternating.Stats.TransitionDensity <= clustered.Stats.TransitionDensity {
+		t.Errorf("TransitionDensity: alternating %.2f should exceed clustered %.2f",
+			alternating.Stats.TransitionDensity, clustered.Stats.TransitionDensity)
+	}
+	if alternating.Stats.TransitionDensity != 5 {
+		t.Errorf("TransitionDensity(%q) = %.2f, want 5", alternating.Text, alternating.Stats.TransitionDensity)
+	}
+}
+
+func TestWeightedAverageScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []int
+		decay  float64
+		want   float64
+	}{
+		{"recent scores weigh more", []int{1, 1, 5}, 0.7, (5 + 0.7 + 0.49) / 2.19},
+		{"decay 1 is the plain mean", []int{1, 1, 5}, 1, 7.0 / 3},
+		{"single score", []int{4}, 0.7, 4},
+		{"empty", nil, 0.7, 0},
+	}
+	for _, tt := range tests {
+		if got := weightedAverageScore(tt.scores, tt.decay); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: weightedAverageScore(%v, %v) = %v, want %v", tt.name, tt.scores, tt.decay, got, tt.want)
+		}
+	}
+
+	if got := weightedAverageScore([]int{1, 1, 5}, 0.7); got <= 7.0/3 {
+		t.Errorf("weightedAverageScore([1 1 5], 0.7) = %v, want above the plain mean %v", got, 7.0/3)
+	}
+}
+
+func TestEmptyCorpus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(path, []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	twisters, err := loadTongueTwisters(path)
+	if err != nil {
+		t.Fatalf("loadTongueTwisters(%q): %v", path, err)
+	}
+	if len(twisters) != 0 {
+		t.Fatalf("loadTongueTwisters(%q) = %d twisters, want 0", path, len(twisters))
+	}
+
+	categories := categorizeTwistersForTraining(twisters, 0)
+	for _, adaptive := range []bool{false, true} {
+		if _, err := selectOptimalTwister(categories, NewUserPerformance(), 1, 3, 0, adaptive); err == nil {
+			t.Errorf("selectOptimalTwister on an empty corpus (adaptive=%v): want an error", adaptive)
+		}
+	}
+}
+
+// analyzedTwisters builds an analyzed corpus from the given texts, as main does after loading
+func analyzedTwisters(texts ...string) []TongueTwister {
+	twisters := make([]TongueTwister, len(texts))
+	for i, text := range texts {
+		twisters[i] = TongueTwister{Number: strconv.Itoa(i + 1), Text: text}
+		analyzeTwister(&twisters[i])
+	}
+	return twisters
+}
+
+// nonInteractiveForTest answers every prompt with the given self-score, without pausing
+func nonInteractiveForTest(t *testing.T, score int) {
+	saved := nonInteractive
+	nonInteractive.Enabled = true
+	nonInteractive.Delay = 0
+	nonInteractive.Score = score
+	t.Cleanup(func() { nonInteractive = saved })
+}
+
+// sessionStateForTest keeps the autosaved perfection session state in a temporary directory
+func sessionStateForTest(t *testing.T) string {
+	saved := sessionStatePath
+	sessionStatePath = filepath.Join(t.TempDir(), sessionStateFileName)
+	t.Cleanup(func() { sessionStatePath = saved })
+	return sessionStatePath
+}
+
+func TestPerfectionSessionSingleRound(t *testing.T) {
+	sessionStateForTest(t)
+	nonInteractiveForTest(t, 4)
+	reportDir := t.TempDir()
+
+	twisters := analyzedTwisters(
+		"Шла Саша по шоссе и сосала сушку.",
+		"Карл у Клары украл кораллы.",
+		"Мама мыла раму.",
+	)
+	runPerfectionTrainingSession(twisters, PerfectionOptions{
+		Level:      3,
+		Adaptive:   true,
+		ScoreDecay: defaultScoreDecay,
+		Rounds:     1,
+		ReportDir:  reportDir,
+	})
+
+	reports, err := loadSessionReports(reportDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("got %d session reports, want 1", len(reports))
+	}
+	if got := len(reports[0].Rounds); got != 1 {
+		t.Errorf("session with Rounds: 1 played %d rounds, want 1", got)
+	}
+	for _, round := range reports[0].Rounds {
+		if math.IsNaN(round.Difficulty) || math.IsInf(round.Difficulty, 0) {
+			t.Errorf("round difficulty = %v, want a finite number", round.Difficulty)
+		}
+	}
+}
+
+func TestGenerateDifficultyProgressionRounds(t *testing.T) {
+	if got := generateDifficultyProgression(3, 1, 1.0); len(got) != 1 || got[0] != 1.0 {
+		t.Errorf("generateDifficultyProgression(3, 1, 1.0) = %v, want [1]", got)
+	}
+	if got := generateDifficultyProgression(3, 0, 1.0); len(got) != 0 {
+		t.Errorf("generateDifficultyProgression(3, 0, 1.0) = %v, want no rounds", got)
+	}
+
+	got := generateDifficultyProgression(3, 5, 1.0)
+	if len(got) != 5 {
+		t.Fatalf("generateDifficultyProgression(3, 5, 1.0) has %d rounds, want 5", len(got))
+	}
+	for i, difficulty := range got {
+		if math.IsNaN(difficulty) || math.IsInf(difficulty, 0) {
+			t.Errorf("round %d difficulty = %v, want a finite number", i+1, difficulty)
+		}
+	}
+}
+
+func TestLongestConsonantRun(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"взгляд", 4},      // взгл
+		{"контрвзгляд", 7}, // нтрвзгл
+		{"Всплеск", 4},     // вспл, case does not matter
+		{"вс плеск", 2},    // runs stop at a space
+		{"аоуэ иы", 0},     // vowels only
+		{"мама", 1},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := longestConsonantRun(tt.text); got != tt.want {
+			t.Errorf("longestConsonantRun(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	twister := TongueTwister{Text: "Контрвзгляд и всплеск"}
+	analyzeTwister(&twister)
+	if twister.Stats.MaxConsonantRun != 7 {
+		t.Errorf("MaxConsonantRun(%q) = %d, want 7", twister.Text, twister.Stats.MaxConsonantRun)
+	}
+}
+
+func TestDigitsToWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"5", "пять"},
+		{"2024", "две тысячи двадцать четыре"},
+		{"21", "двадцать один"},
+		{"1000", "одна тысяча"},
+		{"0", "ноль"},
+		{"1 кот и 2 кота", "один кот и два кота"},
+		{"без цифр", "без цифр"},
+	}
+	for _, tt := range tests {
+		if got := digitsToWords(tt.text); got != tt.want {
+			t.Errorf("digitsToWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeTwisterExpandDigits(t *testing.T) {
+	saved := expandDigits
+	t.Cleanup(func() { expandDigits = saved })
+
+	expandDigits = false
+	plain := TongueTwister{Text: "5 котят в 2024 году"}
+	analyzeTwister(&plain)
+
+	expandDigits = true
+	expanded := TongueTwister{Text: plain.Text}
+	analyzeTwister(&expanded)
+
+	// "пять котят в две тысячи двадцать четыре году"
+	if expanded.Stats.WordCount != 8 {
+		t.Errorf("WordCount with -expand-digits = %d, want 8", expanded.Stats.WordCount)
+	}
+	if expanded.Stats.CharCount <= plain.Stats.CharCount {
+		t.Errorf("CharCount with -expand-digits = %d, want more than %d without it",
+			expanded.Stats.CharCount, plain.Stats.CharCount)
+	}
+}
+
+func TestFilterTwistersByRegex(t *testing.T) {
+	twisters := []TongueTwister{
+		{Number: "1", Text: "Шла Саша по шоссе"},
+		{Number: "2", Text: "Жук жужжит"},
+		{Number: "3", Text: "Карл у Клары украл кораллы"},
+		{Number: "4", Text: "шишки на сосне"},
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{`[шщ]`, []string{"1", "4"}},           // Cyrillic character class, case-sensitive
+		{`(?i)^[шж]`, []string{"1", "2", "4"}}, // case folding works on Cyrillic too
+		{`\p{Cyrillic}{7,}`, []string{"3"}},    // "кораллы"
+		{`[a-z]`, nil},
+	}
+	for _, tt := range tests {
+		filtered := filterTwistersByRegex(twisters, regexp.MustCompile(tt.pattern))
+		var got []string
+		for _, twister := range filtered {
+			got = append(got, twister.Number)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("filterTwistersByRegex(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestAudienceReordersTwisters(t *testing.T) {
+	saved := scoreWeights
+	t.Cleanup(func() { scoreWeights = saved })
+
+	const long = "Мама мыла раму, а папа пил чай у окна дома." // long, easy sounds
+	const clustered = "Взгляд контрвзгляда"                    // short, heavy clusters
+	scores := func(audience string) (longScore, clusteredScore float64) {
+		scoreWeights = audienceWeights[audience]
+		twisters := analyzedTwisters(long, clustered)
+		return twisters[0].Score, twisters[1].Score
+	}
+
+	if longScore, clusteredScore := scores(AudienceAdult); longScore <= clusteredScore {
+		t.Errorf("adult: long %.2f should score above clustered %.2f", longScore, clusteredScore)
+	}
+	if longScore, clusteredScore := scores(AudienceChild); longScore >= clusteredScore {
+		t.Errorf("child: long %.2f should score below clustered %.2f", longScore, clusteredScore)
+	}
+
+	twisters := analyzedTwisters(long, clustered, "Мама мыла раму.")
+	var kept []string
+	for _, twister := range shorterHalf(twisters) {
+		kept = append(kept, twister.Text)
+	}
+	if len(kept) != 2 || kept[0] != clustered || kept[1] != "Мама мыла раму." {
+		t.Errorf("shorterHalf kept %q, want the two short twisters", kept)
+	}
+}
+
+// testFont returns a TrueType font with Cyrillic glyphs: a system font, or the DejaVu font
+// shipped with gofpdf in the module cache
+func testFont(t *testing.T) string {
+	candidates := append([]string{}, pdfFontCandidates...)
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" {
+		modCache = filepath.Join(build.Default.GOPATH, "pkg", "mod")
+	}
+	shipped, _ := filepath.Glob(filepath.Join(modCache, "github.com", "jung-kurt", "gofpdf@*", "font", "DejaVuSansCondensed.ttf"))
+	candidates = append(candidates, shipped...)
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	t.Skip("no font with Cyrillic glyphs found")
+	return ""
+}
+
+func TestRenderPracticeSheet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sheet.pdf")
+	twisters := analyzedTwisters("Шла Саша по шоссе и сосала сушку.", "Карл у Клары украл кораллы.")
+	if err := renderPracticeSheet(twisters, path, testFont(t)); err != nil {
+		t.Fatalf("renderPracticeSheet: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		t.Fatal("the PDF file is empty")
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		t.Errorf("the file starts with %q, want %%PDF", data[:min(len(data), 8)])
+	}
+
+	if err := renderPracticeSheet(twisters, path, filepath.Join(t.TempDir(), "missing.ttf")); err == nil {
+		t.Error("renderPracticeSheet with a missing font: want an error")
+	}
+}
+
+func TestRepeatedWordsVsRepeatedChars(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantWords int
+		wantRoots int
+	}{
+		{"мама мама мама", 2, 0},
+		{"Мама, мама!", 1, 0}, // case and punctuation do not matter
+		{"мама мыла раму", 0, 0},
+		{"говорит говорю", 0, 1}, // same root, different words
+		{"говорит говорит", 1, 0},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := repeatedWordCount(tt.text); got != tt.wantWords {
+			t.Errorf("repeatedWordCount(%q) = %d, want %d", tt.text, got, tt.wantWords)
+		}
+		if got := repeatedRootApprox(tt.text); got != tt.wantRoots {
+			t.Errorf("repeatedRootApprox(%q) = %d, want %d", tt.text, got, tt.wantRoots)
+		}
+	}
+
+	// Letters repeat across different words, but no word is said twice
+	twister := TongueTwister{Text: "Мама мыла раму"}
+	analyzeTwister(&twister)
+	if twister.Stats.RepeatChars == 0 || twister.Stats.RepeatedWords != 0 {
+		t.Errorf("%q: RepeatChars = %d, RepeatedWords = %d, want repeated letters but no repeated words",
+			twister.Text, twister.Stats.RepeatChars, twister.Stats.RepeatedWords)
+	}
+}
+
+func TestParseFocus(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"articulation", 0, false},
+		{" Speed ", 4, false},
+		{"2", 2, false},
+		{"0", 0, false},
+		{"9", 0, true},
+		{"-1", 0, true},
+		{"dance", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseFocus(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFocus(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err != nil && !strings.Contains(err.Error(), "(or 0-4)") {
+			t.Errorf("parseFocus(%q) error = %q, want the list of valid values", tt.value, err)
+		}
+		if got != tt.want {
+			t.Errorf("parseFocus(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+// corpusPath is the full scraped corpus, relative to this package
+const corpusPath = "../../tongue_twisters/all_twisters.json"
+
+func BenchmarkDecodeTwisters(b *testing.B) {
+	data, err := os.ReadFile(corpusPath)
+	if err != nil {
+		b.Skipf("corpus not available: %v", err)
+	}
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeTwisters(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadTongueTwisters(b *testing.B) {
+	if _, err := os.Stat(corpusPath); err != nil {
+		b.Skipf("corpus not available: %v", err)
+	}
+
+	// loadTongueTwisters reports the file it loaded on stderr
+	stderr := os.Stderr
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	b.Cleanup(func() {
+		os.Stderr.Close()
+		os.Stderr = stderr
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loadTongueTwisters(corpusPath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateVariations(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{
+			"Шла Саша по шоссе и сосала сушку.",
+			[]string{"Шла Саша по шоссе и сосала сосала сушку."},
+		},
+		{
+			"Карл у Клары украл кораллы, а Клара у Карла украла кларнет.",
+			[]string{
+				"А Клара у Карла украла кларнет. Карл у Клары украл кораллы,",         // clauses swapped
+				"Карл у Клары украл кораллы кораллы, а Клара у Карла украла кларнет.", // hardest word doubled
+				"Карл у Клары украл кораллы,",                                         // hardest clause alone
+			},
+		},
+		{
+			"На болоте, на моху,\nУкусил комар блоху.",
+			[]string{
+				"Укусил комар блоху.\nНа болоте, на моху,", // lines swapped
+				"На болоте, на моху,\nУкусил Укусил комар блоху.",
+				"Укусил комар блоху.",
+			},
+		},
+		{"Жук", []string{"Жук Жук"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := generateVariations(tt.text)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("generateVariations(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		seen := map[string]bool{tt.text: true}
+		for _, variation := range got {
+			if seen[variation] {
+				t.Errorf("generateVariations(%q) repeats %q or the original text", tt.text, variation)
+			}
+			seen[variation] = true
+		}
+	}
+}
+
+func TestSamplerPick(t *testing.T) {
+	for _, sampler := range []*Sampler{nil, NewSampler()} {
+		if twister, ok := sampler.Pick(nil); ok {
+			t.Errorf("Pick(nil) = %q, true; want ok == false", twister.Text)
+		}
+	}
+
+	sampler := NewSampler()
+	candidates := analyzedTwisters("Мама мыла раму.", "Жук жужжит.")
+	seen := make(map[string]bool)
+	for range candidates {
+		twister, ok := sampler.Pick(candidates)
+		if !ok {
+			t.Fatal("Pick on a non-empty list: ok == false")
+		}
+		if seen[twister.Text] {
+			t.Errorf("Pick returned %q again before every candidate was shown", twister.Text)
+		}
+		seen[twister.Text] = true
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	saved := normalizeYo
+	t.Cleanup(func() { normalizeYo = saved })
+
+	tests := []struct {
+		text string
+		yo   bool
+		want string
+	}{
+		{"ёж", false, "ёж"},             // ё typed as е + combining diaeresis
+		{"мой", false, "мой"},           // й typed as и + combining breve
+		{"за́мок", false, "замок"},       // stress mark dropped
+		{"ѝ", false, "и"},                // stress mark precomposed into the letter
+		{"Ёлка и ёж", true, "Елка и еж"}, // -normalize-yo
+		{"ёлка", true, "елка"},          // decomposed ё too
+		{"Ёлка и ёж", false, "Ёлка и ёж"},
+	}
+	for _, tt := range tests {
+		normalizeYo = tt.yo
+		if got := normalizeText(tt.text); got != tt.want {
+			t.Errorf("normalizeText(%q) with normalizeYo=%v = %q, want %q", tt.text, tt.yo, got, tt.want)
+		}
+	}
+
+	// Decomposed and precomposed input analyze the same once normalized
+	normalizeYo = false
+	composed := TongueTwister{Text: normalizeText("Ёжик мой")}
+	decomposed := TongueTwister{Text: normalizeText("Ёжик мой")}
+	analyzeTwister(&composed)
+	analyzeTwister(&decomposed)
+	if composed.Stats != decomposed.Stats || composed.Score != decomposed.Score {
+		t.Errorf("decomposed text analyzes as %+v, want %+v", decomposed.Stats, composed.Stats)
+	}
+}
+
+func TestTwisterIDStable(t *testing.T) {
+	// The ID of an unnumbered twister is stored in profiles and reports, so it must never change:
+	// "t" + the first 10 hex digits of sha1("мама мыла раму")
+	const want = "t87d990400f"
+	for _, text := range []string{
+		"Мама мыла раму.",
+		"мама  мыла\nраму",
+		"МАМА МЫЛА РАМУ!",
+	} {
+		if got := twisterID(TongueTwister{Text: text}); got != want {
+			t.Errorf("twisterID(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	if got := twisterID(TongueTwister{Number: " 42 ", Text: "Мама мыла раму."}); got != "42" {
+		t.Errorf("twisterID with a number = %q, want 42", got)
+	}
+	if twisterID(TongueTwister{Text: "ёлка"}) != twisterID(TongueTwister{Text: "елка"}) {
+		t.Error("twisterID differs between ё and е spellings")
+	}
+	if twisterID(TongueTwister{Text: "Мама мыла раму."}) == twisterID(TongueTwister{Text: "Мама мыла Милу."}) {
+		t.Error("different texts got the same twisterID")
+	}
+}
+
+func TestSessionStateLocation(t *testing.T) {
+	// Profiles in one directory get separate session files
+	for profile, want := range map[string]string{
+		filepath.Join("data", "a.json"): filepath.Join("data", "a.session.json"),
+		filepath.Join("data", "b.json"): filepath.Join("data", "b.session.json"),
+		"student":                       "student.session.json",
+	} {
+		if got := sessionStateLocation(profile); got != want {
+			t.Errorf("sessionStateLocation(%q) = %q, want %q", profile, got, want)
+		}
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		want := filepath.Join(cacheDir, "easy_trainer", sessionStateFileName)
+		if got := sessionStateLocation(""); got != want {
+			t.Errorf("sessionStateLocation(\"\") = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSessionStateKeepsScoreDecay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", sessionStateFileName)
+	state := SessionState{
+		TotalRounds:  2,
+		Twisters:     analyzedTwisters("Мама мыла раму."),
+		Difficulties: []float64{1, 2},
+		ScoreDecay:   0.5,
+	}
+	if err := saveSessionState(path, state); err != nil {
+		t.Fatalf("saveSessionState: %v", err)
+	}
+	loaded, err := loadSessionState(path)
+	if err != nil {
+		t.Fatalf("loadSessionState: %v", err)
+	}
+	if loaded.ScoreDecay != 0.5 {
+		t.Errorf("ScoreDecay after a round trip = %v, want 0.5", loaded.ScoreDecay)
+	}
+}
+
+func TestTokenizeWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Цу-цу-цу - дали нам по огурцу.", []string{"Цу-цу-цу", "дали", "нам", "по", "огурцу"}}, // a spaced hyphen is a dash
+		{"Пэр — пример", []string{"Пэр", "пример"}},
+		{"раз–два", []string{"раз", "два"}}, // en dash splits words
+		{"из-за угла", []string{"из-за", "угла"}},
+		{"кто- то", []string{"кто-то"}}, // hyphen touching one side only
+		{"кто -то", []string{"кто-то"}},
+		{"по‑русски", []string{"по-русски"}}, // non-breaking hyphen
+		{"«Ёж», — сказал он.", []string{"Ёж", "сказал", "он"}},
+		{"1-й раз", []string{"1-й", "раз"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := tokenizeWords(tt.text); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("tokenizeWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSoftHardSignsAndSemivowel(t *testing.T) {
+	saved := semivowelPolicy
+	t.Cleanup(func() { semivowelPolicy = saved })
+
+	tests := []struct {
+		policy          string
+		text            string
+		wantTransitions int
+		wantRun         int
+	}{
+		// ь and ъ are not sounds: they neither add a transition nor extend a run
+		{SemivowelConsonant, "подъезд", 4, 2},
+		{SemivowelConsonant, "семья", 3, 1},
+		{SemivowelConsonant, "вьюга", 3, 1},
+		// й follows -semivowel
+		{SemivowelConsonant, "район", 4, 1},
+		{SemivowelVowel, "район", 2, 1},
+		{SemivowelSkip, "район", 2, 1},
+		{SemivowelConsonant, "войска", 3, 3},
+		{SemivowelVowel, "войска", 3, 2},
+		{SemivowelSkip, "войска", 3, 2},
+	}
+	for _, tt := range tests {
+		semivowelPolicy = tt.policy
+		if got := transitionCount(tt.text); got != tt.wantTransitions {
+			t.Errorf("%s: transitionCount(%q) = %d, want %d", tt.policy, tt.text, got, tt.wantTransitions)
+		}
+		if got := longestConsonantRun(tt.text); got != tt.wantRun {
+			t.Errorf("%s: longestConsonantRun(%q) = %d, want %d", tt.policy, tt.text, got, tt.wantRun)
+		}
+	}
+
+	semivowelPolicy = SemivowelConsonant
+	for _, tt := range []struct {
+		text                       string
+		wantVowels, wantConsonants int
+	}{
+		{"подъезд", 2, 4},
+		{"семья", 2, 2},
+	} {
+		twister := TongueTwister{Text: tt.text}
+		analyzeTwister(&twister)
+		if twister.Stats.VowelCount != tt.wantVowels || twister.Stats.ConsonantCount != tt.wantConsonants {
+			t.Errorf("%q: %d vowels and %d consonants, want %d and %d", tt.text,
+				twister.Stats.VowelCount, twister.Stats.ConsonantCount, tt.wantVowels, tt.wantConsonants)
+		}
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		page, perPage, total int
+		wantStart, wantEnd   int
+	}{
+		{1, 20, 45, 0, 20},
+		{3, 20, 45, 40, 45}, // last, partial page
+		{4, 20, 45, 45, 45}, // past the end
+		{1, 20, 0, 0, 0},
+		{92233720368547758, 100, 45, 45, 45}, // (page-1)*perPage would overflow
+		{math.MaxInt, 100, 45, 45, 45},
+	}
+	for _, tt := range tests {
+		start, end := pageBounds(tt.page, tt.perPage, tt.total)
+		if start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("pageBounds(%d, %d, %d) = %d, %d, want %d, %d",
+				tt.page, tt.perPage, tt.total, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestSortedNumbers(t *testing.T) {
+	var twisters []TongueTwister
+	for _, number := range []string{"12a", "100", "t3f", "9", "2", "12b"} {
+		twisters = append(twisters, TongueTwister{Number: number})
+	}
+	want := "2,9,100,12a,12b,t3f"
+	for i := 0; i < 10; i++ {
+		rand.Shuffle(len(twisters), func(i, j int) { twisters[i], twisters[j] = twisters[j], twisters[i] })
+		if got := strings.Join(sortedNumbers(twisters), ","); got != want {
+			t.Fatalf("sortedNumbers = %s, want %s", got, want)
+		}
+	}
+}
+
+// quietStdout discards what the function under test prints
+func quietStdout(t *testing.T) {
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = devNull
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+	})
+}
+
+func TestAnalyzeTwisterAfterReload(t *testing.T) {
+	quietStdout(t)
+	twisters := analyzedTwisters(
+		"Шла Саша по шоссе и сосала сушку.",
+		"Карл у Клары украл кораллы.",
+		"Жужжит жужелица, жужжит, да не кружится.",
+	)
+	want := make(map[string]TongueTwister)
+	for _, twister := range twisters {
+		want[twister.Text] = twister
+	}
+
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "analyzed.json")
+	if err := dumpAnalyzed(dump, twisters); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportBySound(filepath.Join(dir, "by-sound"), twisters); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := filepath.Glob(filepath.Join(dir, "by-sound", "*.json"))
+	if err != nil || len(exported) == 0 {
+		t.Fatalf("exportBySound wrote no files (%v)", err)
+	}
+
+	for _, path := range append([]string{dump}, exported...) {
+		reloaded, err := loadTongueTwisters(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range reloaded {
+			analyzeTwister(&reloaded[i])
+			original := want[reloaded[i].Text]
+			if reloaded[i].Stats != original.Stats || reloaded[i].Score != original.Score {
+				t.Errorf("%s: %q reanalyzed as %+v (score %.2f), want %+v (score %.2f)",
+					filepath.Base(path), reloaded[i].Text, reloaded[i].Stats, reloaded[i].Score,
+					original.Stats, original.Score)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"testing"
+)
+
+var updateSnapshot = flag.Bool("update", false, "rewrite testdata/scoring_snapshot.json with the current scores")
+
+// scoringSnapshot is one entry of testdata/scoring_snapshot.json
+type scoringSnapshot struct {
+	Name  string  `json:"name,omitempty"`
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+	Level string  `json:"level"`
+}
+
+// TestScoringSnapshot pins the score and level of a fixed set of twisters, so a change to the
+// scoring shows up as a diff of the fixture. Run with -update to accept new scores.
+func TestScoringSnapshot(t *testing.T) {
+	const path = "testdata/scoring_snapshot.json"
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot []scoringSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("parse %s: %v", path, err)
+	}
+
+	for i, entry := range snapshot {
+		twister := TongueTwister{Text: entry.Text}
+		analyzeTwister(&twister)
+		score := math.Round(twister.Score*100) / 100
+		level := twisterLevel(twister)
+		name := entry.Name
+		if name == "" {
+			name = entry.Text
+		}
+
+		if *updateSnapshot {
+			snapshot[i].Score = score
+			snapshot[i].Level = level
+			continue
+		}
+		if math.Abs(score-entry.Score) > 0.005 {
+			t.Errorf("%s: Score = %.2f, want %.2f", name, score, entry.Score)
+		}
+		if level != entry.Level {
+			t.Errorf("%s: level = %s, want %s", name, level, entry.Level)
+		}
+	}
+
+	if *updateSnapshot {
+		data, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 module tonguetwisters
 
-go 1.20
+go 1.21
 
-require github.com/PuerkitoBio/goquery v1.8.1
+require (
+	github.com/PuerkitoBio/goquery v1.8.1
+	github.com/jung-kurt/gofpdf v1.16.2
+	github.com/mattn/go-sqlite3 v1.14.22
+	golang.org/x/text v0.13.0
+	golang.org/x/time v0.5.0
+)
 
 require (
 	github.com/andybalholm/cascadia v1.3.1 // indirect
 	golang.org/x/net v0.7.0 // indirect
-) 
\ No newline at end of file
+)
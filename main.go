@@ -1,20 +1,40 @@
 package main
 
 import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha1"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/PuerkitoBio/goquery"
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/time/rate"
 )
 
 // TongueTwister represents a single tongue twister with its metadata
@@ -22,64 +42,579 @@ type TongueTwister struct {
 	Number string `json:"number"`
 	Date   string `json:"date"`
 	Text   string `json:"text"`
+	Source string `json:"source,omitempty"` // URL of the page the twister was scraped from
+	Author string `json:"author,omitempty"` // Author, when the site credits one
+}
+
+// ErrPageNotFound is returned when the site answers with its generic "page not found"
+// stub instead of a listing, which happens for page numbers past the end of pagination
+var ErrPageNotFound = errors.New("page not found")
+
+// ErrPageGone is returned when the site answers 404 Not Found or 410 Gone. Unlike the stub
+// it doesn't mark the end of pagination: the page failed and won't come back on retry.
+var ErrPageGone = errors.New("page gone")
+
+// StatusError is returned when the site answers with an unexpected HTTP status
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-200 status code: %d", e.Code)
+}
+
+// Retry pacing: the pause doubles from retryBaseDelay after every failed attempt, up to retryMaxDelay
+const (
+	retryBaseDelay = 1 * time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// notFoundMarkers are lower-cased phrases that identify the site's "page not found" stub
+var notFoundMarkers = []string{
+	"страница не найдена",
+	"page not found",
+	"ошибка 404",
+}
+
+// relatedLinkSelector matches links to related twisters that live outside the main pagination
+const relatedLinkSelector = "table.bgcolor4 a[href], .related a[href]"
+
+// Job is a single page for a worker to scrape
+type Job struct {
+	PageNum int    // Page number in the main pagination, 0 for related pages
+	URL     string // Page URL
+	Depth   int    // How many related links were followed to reach this page
 }
 
 // PageResult represents the result from scraping a single page
 type PageResult struct {
 	PageNum   int
+	Depth     int
 	Twisters  []TongueTwister
+	Related   []string
 	Error     error
 }
 
+// Throttle adapts the scraping pace to how the server is coping. It keeps the outcomes of
+// recent requests from all workers; when too many of them fail it pauses some workers and
+// lengthens the delay between requests, and it relaxes step by step once errors subside.
+type Throttle struct {
+	mu         sync.Mutex
+	maxWorkers int
+	active     int           // Workers with id <= active may send requests
+	baseDelay  time.Duration // Shortest delay between requests when not throttled
+	delay      time.Duration // Current shortest delay
+	jitter     time.Duration // Random extra delay, so workers don't fire in sync
+	outcomes   []bool // Recent request outcomes, true for errors
+	lastChange time.Time
+}
+
+const (
+	throttleWindow     = 20               // Number of recent requests the error rate is computed over
+	throttleMinSamples = 5                // Requests needed before the pace is tightened
+	throttleHighRate   = 0.3              // Error rate that triggers throttling
+	throttleLowRate    = 0.1              // Error rate below which throttling is relaxed
+	throttleCooldown   = 10 * time.Second // Minimum time between relaxation steps
+	throttleMaxDelay   = 8 * time.Second
+	throttleFirstDelay = 500 * time.Millisecond // Delay used when throttling starts from a zero delay
+	throttlePollDelay  = 500 * time.Millisecond
+)
+
+// NewThrottle creates a throttle that starts with all workers running, pausing a random
+// duration between minDelay and maxDelay after each request
+func NewThrottle(workers int, minDelay, maxDelay time.Duration) *Throttle {
+	return &Throttle{
+		maxWorkers: workers,
+		active:     workers,
+		baseDelay:  minDelay,
+		delay:      minDelay,
+		jitter:     maxDelay - minDelay,
+		lastChange: time.Now(),
+	}
+}
+
+// Record adds the outcome of a request. A "page not found" answer means the server is fine.
+func (t *Throttle) Record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	t.outcomes = append(t.outcomes, err != nil && !errors.Is(err, ErrPageNotFound))
+	if len(t.outcomes) > throttleWindow {
+		t.outcomes = t.outcomes[len(t.outcomes)-throttleWindow:]
+	}
+	t.adjustLocked()
+}
+
+// Wait blocks while the worker with this id is paused and reports whether it may go on;
+// it returns false as soon as ctx is cancelled
+func (t *Throttle) Wait(ctx context.Context, id int) bool {
+	for {
+		t.mu.Lock()
+		t.adjustLocked()
+		allowed := id <= t.active
+		t.mu.Unlock()
+		
+		if allowed {
+			return true
+		}
+		if !sleepContext(ctx, throttlePollDelay) {
+			return false
+		}
+	}
+}
+
+// Delay returns a random pause for one worker: the current shortest delay plus up to the jitter
+func (t *Throttle) Delay() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.delay + time.Duration(rand.Int63n(int64(t.jitter)+1))
+}
+
+// adjustLocked tightens or relaxes the pace based on the recent error rate; t.mu must be held
+func (t *Throttle) adjustLocked() {
+	errorCount := 0
+	for _, failed := range t.outcomes {
+		if failed {
+			errorCount++
+		}
+	}
+	rate := 0.0
+	if len(t.outcomes) > 0 {
+		rate = float64(errorCount) / float64(len(t.outcomes))
+	}
+	
+	throttled := t.active < t.maxWorkers || t.delay > t.baseDelay
+	switch {
+	case len(t.outcomes) >= throttleMinSamples && rate > throttleHighRate:
+		if t.active > 1 {
+			t.active /= 2
+		}
+		if t.delay *= 2; t.delay == 0 {
+			t.delay = throttleFirstDelay
+		} else if t.delay > throttleMaxDelay {
+			t.delay = throttleMaxDelay
+		}
+		slog.Warn("Throttle: slowing down", "error_rate", fmt.Sprintf("%.0f%%", rate*100), "workers", t.active, "delay", t.delay)
+	case throttled && time.Since(t.lastChange) >= throttleCooldown &&
+		(rate < throttleLowRate || len(t.outcomes) < throttleMinSamples):
+		// Too few requests since the last change also counts as calm: paused workers may be
+		// holding the only remaining jobs, and waiting for more outcomes would never end
+		if t.active < t.maxWorkers {
+			t.active++
+		}
+		if t.delay /= 2; t.delay < t.baseDelay {
+			t.delay = t.baseDelay
+		}
+		slog.Info("Throttle: speeding up", "error_rate", fmt.Sprintf("%.0f%%", rate*100), "workers", t.active, "delay", t.delay)
+	default:
+		return
+	}
+	
+	// Judge the new pace on fresh outcomes only
+	t.outcomes = nil
+	t.lastChange = time.Now()
+}
+
+// RetryBudget caps the number of retries made by all workers together
+type RetryBudget struct {
+	limited   bool
+	remaining atomic.Int64
+	exhausted sync.Once
+}
+
+// NewRetryBudget creates a budget of n retries; n <= 0 means unlimited
+func NewRetryBudget(n int) *RetryBudget {
+	b := &RetryBudget{limited: n > 0}
+	b.remaining.Store(int64(n))
+	return b
+}
+
+// Take uses up one retry and reports whether it was available.
+// The first refusal is logged so it is clear why pages stopped being retried.
+func (b *RetryBudget) Take() bool {
+	if !b.limited {
+		return true
+	}
+	if b.remaining.Add(-1) >= 0 {
+		return true
+	}
+	b.exhausted.Do(func() {
+		slog.Warn("Retry budget exhausted: failed pages are no longer retried")
+	})
+	return false
+}
+
+// PageOrder hands out pagination results strictly in page order. Results that arrive early
+// wait until every page before them is done, so each result is handled once.
+type PageOrder struct {
+	next      int                // Next page in line
+	buffered  map[int]PageResult // Results that arrived before their turn
+	completed map[int]bool       // Pages with nothing left to save, e.g. restored from a checkpoint
+}
+
+// NewPageOrder creates an ordering that starts at page 1 and skips the completed pages
+func NewPageOrder(completed map[int]bool) *PageOrder {
+	o := &PageOrder{next: 1, buffered: make(map[int]PageResult), completed: completed}
+	o.skipCompleted()
+	return o
+}
+
+// NextPage returns the page that has to be handed out next
+func (o *PageOrder) NextPage() int {
+	return o.next
+}
+
+// Add stores the result of a pagination page until it is next in line
+func (o *PageOrder) Add(result PageResult) {
+	o.buffered[result.PageNum] = result
+}
+
+// Next returns the result of the next page in line, if it has arrived and is not past lastPage
+func (o *PageOrder) Next(lastPage int) (PageResult, bool) {
+	o.skipCompleted()
+	if o.next > lastPage {
+		return PageResult{}, false
+	}
+	result, ok := o.buffered[o.next]
+	if !ok {
+		// This page hasn't arrived yet, so we need to wait
+		return PageResult{}, false
+	}
+	delete(o.buffered, o.next)
+	o.next++
+	return result, true
+}
+
+func (o *PageOrder) skipCompleted() {
+	for o.completed[o.next] {
+		o.next++
+	}
+}
+
+// siteDateLayouts are the formats of the date shown next to each twister ("Дата: 10.01.2016, 14:23")
+var siteDateLayouts = []string{"02.01.2006, 15:04", "02.01.2006"}
+
+// parseTwisterDate parses a scraped date, with or without the "Дата:" label
+func parseTwisterDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "Дата:"))
+	for _, layout := range siteDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q, expected DD.MM.YYYY[, HH:MM]", value)
+}
+
+// DateFilter keeps twisters dated within [since, until]; a zero bound means no limit on that side
+type DateFilter struct {
+	since, until time.Time
+	mu           sync.Mutex
+	outside      int // Twisters dropped for being out of range
+	unparseable  int // Twisters dropped because their date could not be read
+}
+
+// NewDateFilter parses -since and -until in the site's date format. Until is inclusive, so
+// the whole day is kept. It returns nil when neither bound is set.
+func NewDateFilter(since, until string) (*DateFilter, error) {
+	if since == "" && until == "" {
+		return nil, nil
+	}
+	
+	f := &DateFilter{}
+	if since != "" {
+		date, err := parseTwisterDate(since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -since: %w", err)
+		}
+		f.since = date
+	}
+	if until != "" {
+		date, err := parseTwisterDate(until)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -until: %w", err)
+		}
+		f.until = date.AddDate(0, 0, 1)
+	}
+	if !f.since.IsZero() && !f.until.IsZero() && !f.since.Before(f.until) {
+		return nil, fmt.Errorf("-since %s is after -until %s", since, until)
+	}
+	return f, nil
+}
+
+// Apply returns the twisters dated within the range. A nil filter keeps everything.
+func (f *DateFilter) Apply(twisters []TongueTwister) []TongueTwister {
+	if f == nil {
+		return twisters
+	}
+	
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var kept []TongueTwister
+	for _, twister := range twisters {
+		date, err := parseTwisterDate(twister.Date)
+		if err != nil {
+			slog.Warn("Dropping tongue twister with an unparseable date", "number", twister.Number, "error", err)
+			f.unparseable++
+			continue
+		}
+		if (!f.since.IsZero() && date.Before(f.since)) || (!f.until.IsZero() && !date.Before(f.until)) {
+			f.outside++
+			continue
+		}
+		kept = append(kept, twister)
+	}
+	return kept
+}
+
+// Summary describes how many twisters the filter dropped
+func (f *DateFilter) Summary() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return fmt.Sprintf("Date filter dropped %d tongue twisters outside the range and %d with unparseable dates",
+		f.outside, f.unparseable)
+}
+
 func main() {
 	// Parse command line flags
 	concurrencyFlag := flag.Int("concurrency", runtime.NumCPU(), "Number of concurrent workers (default: number of CPU cores)")
 	outputDirFlag := flag.String("output", "tongue_twisters", "Directory to save output files")
+	outNameFlag := flag.String("out-name", "all_twisters.json", "Name of the combined JSON file written inside the output directory")
+	followRelatedFlag := flag.Bool("follow-related", false, "Also scrape pages linked as related twisters")
+	delayMinFlag := flag.Duration("delay-min", 300*time.Millisecond, "Shortest pause after each request")
+	delayMaxFlag := flag.Duration("delay-max", 800*time.Millisecond, "Longest pause after each request; each pause is random between -delay-min and -delay-max")
+	retryBudgetFlag := flag.Int("retry-budget", 0, "Maximum number of retries across all workers (0 = unlimited)")
+	toleranceFlag := flag.Int("total-tolerance", 20, "Warn if the number of scraped twisters differs from the site's declared total by more than this")
+	sinceFlag := flag.String("since", "", "Only keep twisters dated on or after this day (DD.MM.YYYY, as on the site)")
+	untilFlag := flag.String("until", "", "Only keep twisters dated on or before this day (DD.MM.YYYY, as on the site)")
+	maxDepthFlag := flag.Int("max-depth", 1, "Maximum number of related links to follow away from the main pagination")
+	resumeFlag := flag.Bool("resume", true, "Skip pages recorded in checkpoint.json in the output directory by an interrupted run (-resume=false starts fresh)")
+	maxBufferedFlag := flag.Int("max-buffered", 0, "Maximum number of pages scraped ahead of the next page to save (0 = unlimited)")
+	urlFlag := flag.String("url", defaultFirstPageURL, "URL of the first page of the category to scrape")
+	urlTemplateFlag := flag.String("url-template", "", "URL of page N with %d for the page number (default: -url with .html replaced by -num%d.html)")
+	incrementalFlag := flag.Bool("incremental", false, "Keep the twisters already in the combined JSON file and only add new ones")
+	stopAfterKnownFlag := flag.Int("stop-after-known", 3, "With -incremental, stop after this many pages in a row with no new twisters (0 = scrape every page)")
+	formatFlag := flag.String("format", "files", "Where each twister is stored besides the combined JSON: files (one text file each) or sqlite")
+	dbFlag := flag.String("db", "twisters.db", "SQLite database file used with -format=sqlite")
+	proxyFlag := flag.String("proxy", "", "Send all requests through this proxy (http://host:port or socks5://host:port)")
+	userAgentsFlag := flag.String("user-agents", "", "File with one User-Agent per line to rotate through (default: a built-in pool of common browsers)")
+	rpsFlag := flag.Float64("rps", 2, "Maximum requests per second across all workers (0 = unlimited)")
+	dryRunFlag := flag.Bool("dry-run", false, "Scrape and report counts and progress without writing any files or database rows")
+	pagesFlag := flag.Int("pages", 0, "Scrape only the first N pages of the pagination (0 = all)")
+	logLevelFlag := flag.String("log-level", "info", "Least severe messages to log: debug, info, warn or error")
+	logFormatFlag := flag.String("log-format", "text", "Log format: text (key=value lines) or json (one object per line)")
 	flag.Parse()
 
+	// Everything is logged to stderr through slog, so progress and errors can be filtered alike
+	logger, err := newLogger(*logLevelFlag, *logFormatFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
 	// Validate concurrency flag
 	concurrency := *concurrencyFlag
 	if concurrency < 1 {
 		concurrency = 1
 	} else if concurrency > 20 {
-		log.Printf("Warning: High concurrency level (%d) might get you rate limited. Consider using a lower value.", concurrency)
+		slog.Warn("High concurrency level might get you rate limited. Consider using a lower value.", "concurrency", concurrency)
 	}
 
-	// Create output directory
-	outputDir := *outputDirFlag
-	err := os.MkdirAll(outputDir, 0755)
+	// A window smaller than the worker pool leaves workers idle
+	maxBuffered := *maxBufferedFlag
+	if maxBuffered < 0 {
+		fatal(fmt.Sprintf("Invalid -max-buffered %d: expected 0 (unlimited) or a positive number of pages", maxBuffered))
+	} else if maxBuffered > 0 && maxBuffered < concurrency {
+		slog.Warn("-max-buffered is below -concurrency; some workers will sit idle", "max_buffered", maxBuffered, "concurrency", concurrency)
+	}
+
+	// Stopping early only saves requests if the rest of the pagination isn't queued up front
+	if *stopAfterKnownFlag < 0 {
+		fatal(fmt.Sprintf("Invalid -stop-after-known %d: expected 0 (never stop) or a positive number of pages", *stopAfterKnownFlag))
+	}
+	stopAfterKnown := 0
+	if *incrementalFlag {
+		stopAfterKnown = *stopAfterKnownFlag
+	}
+	if stopAfterKnown > 0 && maxBuffered == 0 {
+		maxBuffered = 2 * concurrency
+	}
+
+	// Pauses are drawn from [delay-min, delay-max]
+	if *delayMinFlag < 0 || *delayMinFlag > *delayMaxFlag {
+		fatal(fmt.Sprintf("Invalid delays: need 0 <= -delay-min (%v) <= -delay-max (%v)", *delayMinFlag, *delayMaxFlag))
+	}
+
+	// Page 1 is -url; later pages come from the template
+	firstURL := *urlFlag
+	urlTemplate := *urlTemplateFlag
+	if urlTemplate == "" {
+		if !strings.HasSuffix(firstURL, ".html") {
+			fatal(fmt.Sprintf("Cannot derive page URLs from -url %q: it does not end in .html, so set -url-template", firstURL))
+		}
+		urlTemplate = strings.ReplaceAll(strings.TrimSuffix(firstURL, ".html"), "%", "%%") + "-num%d.html"
+	}
+	if err := validateURLTemplate(urlTemplate); err != nil {
+		fatal(fmt.Sprintf("Invalid -url-template %q: %v", urlTemplate, err))
+	}
+	urlForPage := func(page int) string {
+		if page == 1 {
+			return firstURL
+		}
+		return pageURL(urlTemplate, page)
+	}
+
+	// Without -proxy the default client honours HTTP_PROXY/HTTPS_PROXY from the environment
+	if *proxyFlag != "" {
+		client, err := newHTTPClient(*proxyFlag)
+		if err != nil {
+			fatal(err.Error())
+		}
+		httpClient = client
+	}
+
+	// Each request picks a random User-Agent from the pool
+	if *userAgentsFlag != "" {
+		agents, err := loadUserAgents(*userAgentsFlag)
+		if err != nil {
+			fatal(err.Error())
+		}
+		userAgents = agents
+	}
+
+	// One limiter for all workers, so the request rate doesn't grow with -concurrency
+	if *rpsFlag < 0 {
+		fatal(fmt.Sprintf("Invalid -rps %v: expected 0 (unlimited) or a positive rate", *rpsFlag))
+	}
+	limit := rate.Limit(*rpsFlag)
+	if *rpsFlag == 0 {
+		limit = rate.Inf
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	// Twisters outside the date range are dropped before saving
+	dateFilter, err := NewDateFilter(*sinceFlag, *untilFlag)
 	if err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+		fatal(err.Error())
+	}
+
+	// The JSON file always lives inside the output directory
+	outName := *outNameFlag
+	if outName == "" || outName != filepath.Base(outName) {
+		fatal(fmt.Sprintf("Invalid -out-name %q: expected a plain file name without directories", outName))
 	}
 
-	// Base URL and total pages (from the HTML: "Всего: 4286 на 215 страницах по 20 на каждой странице")
-	baseURL := "https://skorogovorki.my-collection.ru/skorogovorki-cat4"
-	totalPages := 215
+	if *pagesFlag < 0 {
+		fatal(fmt.Sprintf("Invalid -pages %d: expected 0 (all) or a positive number of pages", *pagesFlag))
+	}
+
+	// A dry run never touches the disk: no output directory, twister files, JSON, checkpoint or database
+	dryRun := *dryRunFlag
+	outputDir := *outputDirFlag
+	if !dryRun {
+		err = os.MkdirAll(outputDir, 0755)
+		if err != nil {
+			fatal("Failed to create output directory", "dir", outputDir, "error", err)
+		}
+	}
+
+	// Every saved twister goes to the configured backend
+	var store TwisterStore = DiscardStore{}
+	if !dryRun {
+		store, err = openStore(*formatFlag, outputDir, *dbFlag)
+		if err != nil {
+			fatal(err.Error())
+		}
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			slog.Error("Error closing the store", "format", *formatFlag, "error", err)
+		}
+	}()
+
+	// Ctrl-C cancels ctx: requests in flight are aborted, workers stop taking jobs,
+	// and the twisters collected so far are saved before exiting
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		slog.Warn("Interrupted: stopping workers and saving the collected twisters (press Ctrl-C again to quit at once)")
+		signal.Stop(signals)
+		cancel()
+	}()
+
+	// Total pages (from the HTML: "Всего: 4286 на 215 страницах по 20 на каждой странице")
+	totalPages, declaredTotal := detectTotalPages(ctx, firstURL, 215)
+	limitedPages := *pagesFlag > 0 && *pagesFlag < totalPages
+	if limitedPages {
+		totalPages = *pagesFlag
+	}
 
 	// Collect all tongue twisters
 	var allTwisters []TongueTwister
 	var mutex sync.Mutex // To protect allTwisters from concurrent access
 	startTime := time.Now()
+	
+	// Pages completed by an interrupted run are skipped; their twisters come back from the saved JSON.
+	// A dry run reports on every page, so it ignores the checkpoint.
+	checkpointPath := filepath.Join(outputDir, checkpointName)
+	var checkpoint Checkpoint
+	if *resumeFlag && !dryRun {
+		saved, twisters, err := loadResumeState(checkpointPath, filepath.Join(outputDir, outName))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			slog.Warn("Cannot resume, starting fresh", "error", err)
+		default:
+			checkpoint = saved
+			allTwisters = twisters
+			slog.Info("Resuming", "completed_pages", len(checkpoint.Completed), "twisters", len(allTwisters), "file", outName)
+		}
+	}
+	
+	// An incremental run starts from the previous result; a resumed one has loaded it already
+	if *incrementalFlag && len(checkpoint.Completed) == 0 {
+		twisters, err := loadTwistersJSON(filepath.Join(outputDir, outName))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			slog.Info("Incremental: no saved twisters, scraping every page", "file", outName)
+		case err != nil:
+			fatal("Cannot scrape incrementally", "error", err)
+		default:
+			allTwisters = twisters
+			slog.Info("Incremental: tongue twisters already known", "twisters", len(allTwisters), "file", outName)
+		}
+	}
 
-	fmt.Printf("Starting to scrape %d pages with %d concurrent workers. This may take a while...\n", 
-		totalPages, concurrency)
+	slog.Info("Starting to scrape. This may take a while...", "pages", totalPages, "workers", concurrency)
 	
-	// Create channels for jobs and results
-	jobs := make(chan int, totalPages)
-	results := make(chan PageResult, totalPages)
+	// Create channels for jobs and results; with -max-buffered only that many results can wait unprocessed
+	resultsBuffer := totalPages
+	if maxBuffered > 0 && maxBuffered < resultsBuffer {
+		resultsBuffer = maxBuffered
+	}
+	jobs := make(chan Job, totalPages)
+	results := make(chan PageResult, resultsBuffer)
 	
-	// Launch worker goroutines
+	// Launch worker goroutines sharing one throttle and one rate limiter
+	throttle := NewThrottle(concurrency, *delayMinFlag, *delayMaxFlag)
+	retryBudget := NewRetryBudget(*retryBudgetFlag)
 	var wg sync.WaitGroup
 	for w := 1; w <= concurrency; w++ {
 		wg.Add(1)
-		go worker(w, baseURL, jobs, results, &wg)
+		go worker(ctx, w, jobs, results, throttle, limiter, retryBudget, &wg)
 	}
 	
-	// Send jobs (page numbers) to the workers
-	for page := 1; page <= totalPages; page++ {
-		jobs <- page
+	// Jobs without a result yet; the jobs channel is closed once nothing is left to scrape
+	pending := 0
+	
+	// Twister numbers already collected, used to skip duplicates found on related pages
+	seenNumbers := make(map[string]bool)
+	for _, twister := range allTwisters {
+		seenNumbers[twister.Number] = true
 	}
-	close(jobs)
 	
 	// Start a goroutine to collect results
 	go func() {
@@ -87,142 +622,576 @@ func main() {
 		close(results)
 	}()
 	
-	// Map to track completed pages and save results in order
+	// Pages are saved strictly in order; pages restored from the checkpoint are never scraped again
 	completed := make(map[int]bool)
-	completedCount := 0
-	resultsByPage := make(map[int]PageResult)
+	for _, page := range checkpoint.Completed {
+		completed[page] = true
+	}
+	completedCount := len(completed)
+	resumedPages := completedCount
+	order := NewPageOrder(completed)
+	
+	// Last page that actually exists; lowered when the site reports a page as not found
+	lastPage := totalPages
+	
+	// Send jobs (page numbers) to the workers; URLs are tracked so related links are never scraped twice.
+	// With -max-buffered, pages are only handed out while they fall inside the window that starts at
+	// the next page in line, so a slow page stalls the scrape instead of letting buffered results grow without bound
+	visited := make(map[string]bool)
+	dispatched := 0
+	dispatchPages := func() {
+		for dispatched < lastPage && (maxBuffered == 0 || dispatched < order.NextPage()-1+maxBuffered) {
+			dispatched++
+			if completed[dispatched] {
+				continue
+			}
+			job := Job{PageNum: dispatched, URL: urlForPage(dispatched)}
+			visited[job.URL] = true
+			pending++
+			
+			// Related jobs may have filled the buffer; as with them, never block result processing
+			select {
+			case jobs <- job:
+			default:
+				go func() {
+					jobs <- job
+				}()
+			}
+		}
+	}
+	dispatchPages()
+	if pending == 0 {
+		close(jobs)
+	}
+	
+	// Pagination pages that failed for good, and twisters collected from the pagination alone
+	failedPages := 0
+	paginationTwisters := checkpoint.PaginationTwisters
+	
+	// Pages in a row without a new twister; with -incremental, enough of them end the scrape
+	knownStreak := 0
+	stoppedEarly := false
 	
 	// Process results as they come in
 	for result := range results {
-		if result.Error != nil {
-			log.Printf("Error scraping page %d: %v", result.PageNum, result.Error)
-			continue
-		}
+		pending--
 		
-		// Store result for ordered processing
-		resultsByPage[result.PageNum] = result
-		
-		// Process results in order when possible
-		for page := 1; page <= totalPages; page++ {
-			if !completed[page] && resultsByPage[page].Twisters != nil {
-				pageResult := resultsByPage[page]
-				
-				// Process the page result
-				mutex.Lock()
-				for _, twister := range pageResult.Twisters {
-					saveToFile(twister, outputDir)
-					allTwisters = append(allTwisters, twister)
+		// Enqueue related pages, bounded by the crawl depth
+		if *followRelatedFlag && result.Depth < *maxDepthFlag {
+			for _, link := range result.Related {
+				if visited[link] {
+					continue
 				}
-				mutex.Unlock()
-				
-				completedCount++
-				completed[page] = true
-				
-				// Calculate and display progress
-				progress := float64(completedCount) / float64(totalPages) * 100
-				elapsed := time.Since(startTime)
-				estimatedTotal := elapsed.Seconds() / (float64(completedCount) / float64(totalPages))
-				remaining := time.Duration(estimatedTotal-elapsed.Seconds()) * time.Second
+				visited[link] = true
+				pending++
 				
-				fmt.Printf("[%.1f%%] Completed page %d: found %d tongue twisters (total so far: %d) (Est. remaining: %v)\n", 
-					progress, page, len(pageResult.Twisters), len(allTwisters), remaining.Round(time.Second))
-				
-				// Save progress periodically (every 20 pages)
-				if completedCount%20 == 0 {
-					mutex.Lock()
-					saveAllToJSON(allTwisters, outputDir)
-					mutex.Unlock()
-					fmt.Printf("Periodic progress saved to JSON after %d pages\n", completedCount)
+				// Send from a goroutine so a full jobs buffer never blocks result processing
+				job := Job{URL: link, Depth: result.Depth + 1}
+				go func() {
+					jobs <- job
+				}()
+			}
+		}
+		
+		switch {
+		case result.PageNum == 0 && result.Error != nil:
+			if !errors.Is(result.Error, ErrPageNotFound) && !errors.Is(result.Error, ErrPageGone) {
+				slog.Error("Error scraping related page", "depth", result.Depth, "error", result.Error)
+			}
+		case result.PageNum == 0:
+			// Related pages are outside the pagination, so they are saved as soon as they arrive
+			added := 0
+			mutex.Lock()
+			for _, twister := range dateFilter.Apply(result.Twisters) {
+				if seenNumbers[twister.Number] {
+					continue
 				}
-			} else if !completed[page] {
-				// This page hasn't been processed yet, so we need to wait
+				seenNumbers[twister.Number] = true
+				saveTwister(store, twister)
+				allTwisters = append(allTwisters, twister)
+				added++
+			}
+			mutex.Unlock()
+			slog.Info("Completed related page", "depth", result.Depth, "new_twisters", added, "total", len(allTwisters))
+		case errors.Is(result.Error, ErrPageNotFound):
+			if result.PageNum-1 < lastPage {
+				lastPage = result.PageNum - 1
+				slog.Info("Page not found, treating the previous page as the end of pagination", "page", result.PageNum, "last_page", lastPage)
+			}
+		default:
+			if result.Error != nil {
+				slog.Error("Error scraping page", "page", result.PageNum, "error", result.Error)
+			}
+			
+			// Store result (failed pages too, so they don't hold back later pages)
+			order.Add(result)
+		}
+		
+		// Process every page that is now next in line
+		for {
+			pageResult, ok := order.Next(lastPage)
+			if !ok {
 				break
 			}
+			page := pageResult.PageNum
+			
+			if pageResult.Error != nil {
+				failedPages++
+				continue
+			}
+			
+			// Process the page result
+			paginationTwisters += len(pageResult.Twisters)
+			fresh := 0
+			for _, twister := range pageResult.Twisters {
+				if !seenNumbers[twister.Number] {
+					fresh++
+				}
+			}
+			mutex.Lock()
+			for _, twister := range dateFilter.Apply(pageResult.Twisters) {
+				if seenNumbers[twister.Number] {
+					continue
+				}
+				seenNumbers[twister.Number] = true
+				saveTwister(store, twister)
+				allTwisters = append(allTwisters, twister)
+			}
+			mutex.Unlock()
+			
+			completedCount++
+			completed[page] = true
+			
+			// Calculate and display progress; the pace only counts pages scraped in this run
+			progress := float64(completedCount) / float64(lastPage) * 100
+			elapsed := time.Since(startTime)
+			perPage := elapsed.Seconds() / float64(completedCount-resumedPages)
+			remaining := time.Duration(perPage*float64(lastPage-completedCount)) * time.Second
+			
+			slog.Info("Completed page", "page", page, "progress", fmt.Sprintf("%.1f%%", progress),
+				"twisters", len(pageResult.Twisters), "total", len(allTwisters), "remaining", remaining.Round(time.Second))
+			
+			// Save progress after every page: the JSON first, so the checkpoint never lists
+			// a page whose twisters are not saved yet
+			checkpoint.Completed = append(checkpoint.Completed, page)
+			checkpoint.PaginationTwisters = paginationTwisters
+			if !dryRun {
+				mutex.Lock()
+				saveAllToJSON(allTwisters, outputDir, outName)
+				mutex.Unlock()
+				saveCheckpoint(checkpointPath, checkpoint)
+			}
+			
+			// New twisters appear on the first pages, so a run of known pages means the rest is known too
+			if fresh == 0 {
+				knownStreak++
+			} else {
+				knownStreak = 0
+			}
+			if stopAfterKnown > 0 && knownStreak >= stopAfterKnown && page < lastPage {
+				lastPage = page
+				stoppedEarly = true
+				slog.Info("Incremental: pages in a row had no new tongue twisters, stopping", "known_pages", knownStreak, "page", page)
+			}
+		}
+		
+		// Saved pages slide the window forward
+		dispatchPages()
+		if pending == 0 {
+			close(jobs)
 		}
 	}
 	
 	// Save all tongue twisters to a single JSON file
-	saveAllToJSON(allTwisters, outputDir)
+	if !dryRun {
+		saveAllToJSON(allTwisters, outputDir, outName)
+	}
+	
+	// After an interrupt the checkpoint stays, so the next run picks up where this one stopped
+	if ctx.Err() != nil && dryRun {
+		slog.Info("Dry run interrupted; nothing was written", "twisters", len(allTwisters))
+		return
+	}
+	if ctx.Err() != nil {
+		slog.Info("Scraping interrupted; run again to resume", "twisters", len(allTwisters), "file", filepath.Join(outputDir, outName))
+		return
+	}
+	
+	// A complete run needs no checkpoint; after failures it lets the next run retry just those pages
+	if dryRun {
+		if failedPages > 0 {
+			slog.Warn("Some pages failed", "failed_pages", failedPages)
+		}
+	} else if failedPages == 0 {
+		if err := os.Remove(checkpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Error("Error removing checkpoint", "file", checkpointPath, "error", err)
+		}
+	} else {
+		slog.Warn("Some pages failed; run again to retry them, completed pages are skipped", "failed_pages", failedPages)
+	}
 	
 	elapsed := time.Since(startTime)
-	fmt.Printf("Scraping completed! Total tongue twisters: %d (Time elapsed: %s)\n", 
-		len(allTwisters), elapsed.Round(time.Second))
+	slog.Info("Scraping completed!", "total", len(allTwisters), "elapsed", elapsed.Round(time.Second))
+	if dryRun {
+		slog.Info("Dry run: nothing was written", "would_save", len(allTwisters), "output", filepath.Join(outputDir, outName))
+	}
+	if dateFilter != nil {
+		slog.Info(dateFilter.Summary())
+	}
+	
+	// Compare the pagination against the total the site declares, to catch silently lost pages;
+	// an incremental run that stopped early or a -pages run has only seen part of the pagination
+	if declaredTotal > 0 && !stoppedEarly && !limitedPages {
+		diff := declaredTotal - paginationTwisters
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > *toleranceFlag {
+			slog.Warn("The site declares more or fewer tongue twisters than were scraped from its pages",
+				"declared", declaredTotal, "scraped", paginationTwisters, "difference", diff, "tolerance", *toleranceFlag, "failed_pages", failedPages)
+		} else {
+			slog.Info("Scraped count is within tolerance of the declared total",
+				"declared", declaredTotal, "difference", diff, "tolerance", *toleranceFlag)
+		}
+	}
 }
 
-// worker function that processes jobs from the jobs channel
-func worker(id int, baseURL string, jobs <-chan int, results chan<- PageResult, wg *sync.WaitGroup) {
+// newLogger builds the logger selected by -log-level and -log-format; both write to stderr
+func newLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level %q: expected debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid -log-format %q: expected text or json", format)
+	}
+}
+
+// fatal logs msg at error level and exits, like log.Fatal did before slog
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// worker function that processes jobs from the jobs channel until it is closed or ctx is cancelled.
+// A page interrupted by cancellation is not reported, so it is neither saved nor counted as failed.
+func worker(ctx context.Context, id int, jobs <-chan Job, results chan<- PageResult, throttle *Throttle, limiter *rate.Limiter, retryBudget *RetryBudget, wg *sync.WaitGroup) {
 	defer wg.Done()
 	
-	for page := range jobs {
-		// Construct page URL
-		pageURL := baseURL
-		if page > 1 {
-			pageURL = fmt.Sprintf("%s-num%d.html", baseURL, page)
-		} else {
-			pageURL = baseURL + ".html"
+	for job := range jobs {
+		if ctx.Err() != nil {
+			return
 		}
+		pageURL := job.URL
 		
-		fmt.Printf("Worker %d: Scraping page %d: %s\n", id, page, pageURL)
+		slog.Debug("Scraping page", "worker", id, "page", job.PageNum, "url", pageURL)
 		
 		// Fetch and parse the page with retry mechanism
 		var twisters []TongueTwister
+		var related []string
 		var err error
 		maxRetries := 3
 		
 		for retries := 0; retries < maxRetries; retries++ {
-			twisters, err = scrapePageTwisters(pageURL)
-			if err == nil {
+			if !throttle.Wait(ctx, id) {
+				return
+			}
+			twisters, related, err = scrapePageTwisters(ctx, limiter, pageURL)
+			if ctx.Err() != nil {
+				return
+			}
+			throttle.Record(err)
+			if err == nil || errors.Is(err, ErrPageNotFound) {
+				break
+			}
+			attrs := []any{"worker", id, "page", job.PageNum, "url", pageURL, "attempt", retries + 1, "max_attempts", maxRetries, "error", err}
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				attrs = append(attrs, "status_code", statusErr.Code)
+			}
+			slog.Warn("Error scraping page", attrs...)
+			if !isTransient(err) || retries == maxRetries-1 || !retryBudget.Take() {
 				break
 			}
-			log.Printf("Worker %d: Error scraping page %d (attempt %d/%d): %v", id, page, retries+1, maxRetries, err)
-			if retries < maxRetries-1 {
-				log.Printf("Worker %d: Retrying in 2 seconds...", id)
-				time.Sleep(2 * time.Second)
+			backoff := retryBackoff(retries)
+			slog.Info("Retrying", "worker", id, "page", job.PageNum, "attempt", retries+2, "backoff", backoff.Round(time.Millisecond))
+			if !sleepContext(ctx, backoff) {
+				return
 			}
 		}
 		
 		results <- PageResult{
-			PageNum:  page,
+			PageNum:  job.PageNum,
+			Depth:    job.Depth,
 			Twisters: twisters,
+			Related:  related,
 			Error:    err,
 		}
 		
-		// Be nice to the server and add a small delay
-		time.Sleep(500 * time.Millisecond)
+		// Be nice to the server and add a random delay, longer while throttled
+		if !sleepContext(ctx, throttle.Delay()) {
+			return
+		}
 	}
 }
 
-// scrapePageTwisters extracts tongue twisters from a single page
-func scrapePageTwisters(url string) ([]TongueTwister, error) {
-	// Make HTTP request with proper headers
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// isTransient reports whether a failed request is worth retrying: timeouts, dropped
+// connections, 5xx answers and 429 Too Many Requests. Anything else, such as a page that
+// doesn't parse or a 404, would fail the same way again.
+func isTransient(err error) bool {
+	if errors.Is(err, ErrPageGone) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500 || statusErr.Code == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryBackoff returns the pause before retry number attempt+1: retryBaseDelay doubled
+// attempt times and capped at retryMaxDelay, with its upper half randomized so workers
+// that failed together don't retry together
+func retryBackoff(attempt int) time.Duration {
+	d := retryMaxDelay
+	if attempt < 16 && retryBaseDelay<<attempt < retryMaxDelay {
+		d = retryBaseDelay << attempt
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// sleepContext pauses for d and reports whether it slept the whole time; it returns false
+// as soon as ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// httpClient is shared by every request, so connections are reused; -proxy replaces it in main
+var httpClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+// userAgents is the pool each request picks its User-Agent from; -user-agents replaces it in main
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 YaBrowser/24.4.0.0 Safari/537.36",
+}
+
+// randomUserAgent picks a User-Agent for one request
+func randomUserAgent() string {
+	return userAgents[rand.Intn(len(userAgents))]
+}
+
+// loadUserAgents reads one User-Agent per line, skipping blank lines and # comments
+func loadUserAgents(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read -user-agents file: %w", err)
+	}
+	var agents []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		agents = append(agents, line)
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("-user-agents file %s has no User-Agent lines", path)
+	}
+	return agents, nil
+}
+
+// newHTTPClient returns a client that sends every request through proxyURL
+// (http://, https:// or socks5://, optionally with user:password@)
+func newHTTPClient(proxyURL string) (*http.Client, error) {
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -proxy %q: %v", proxyURL, err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid -proxy %q: expected an http://, https:// or socks5:// URL", proxyURL)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("invalid -proxy %q: missing host", proxyURL)
 	}
 	
-	req, err := http.NewRequest("GET", url, nil)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}, nil
+}
+
+// defaultFirstPageURL is the first page of the category scraped when -url is not given
+const defaultFirstPageURL = "https://skorogovorki.my-collection.ru/skorogovorki-cat4.html"
+
+// pageURL returns the URL of a page in the main pagination from a template such as
+// "https://skorogovorki.my-collection.ru/skorogovorki-cat1-num%d.html"
+func pageURL(template string, page int) string {
+	return fmt.Sprintf(template, page)
+}
+
+// validateURLTemplate checks that a page URL template takes exactly one page number
+func validateURLTemplate(template string) error {
+	if strings.Count(strings.ReplaceAll(template, "%%", ""), "%") != 1 || !strings.Contains(template, "%d") {
+		return errors.New("expected exactly one %d for the page number (write a literal % as %%)")
+	}
+	if _, err := url.Parse(pageURL(template, 2)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// fetchDocument downloads a page and parses its HTML; cancelling ctx aborts the request
+func fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	// Make HTTP request with proper headers
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	req.Header.Set("User-Agent", randomUserAgent())
+	// Setting this turns off the transport's own decompression, so decodeBody does it below
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
 	
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch page: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// A missing page won't come back on retry, so it gets its own permanent error
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("received status code %d: %w", resp.StatusCode, ErrPageGone)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+		return nil, &StatusError{Code: resp.StatusCode}
+	}
+
+	body, err := decodeBody(resp)
+	if err != nil {
+		return nil, err
 	}
+	defer body.Close()
 
 	// Parse HTML
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
+	return doc, nil
+}
+
+// decodeBody returns the response body decompressed according to its Content-Encoding;
+// an uncompressed body is returned as is. Closing the result does not close resp.Body.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip body: %w", err)
+		}
+		return reader, nil
+	case "deflate":
+		// The standard wraps deflate in zlib, but some servers send a raw deflate stream
+		buffered := bufio.NewReader(resp.Body)
+		header, err := buffered.Peek(2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read deflate body: %w", err)
+		}
+		if !isZlibHeader(header) {
+			return flate.NewReader(buffered), nil
+		}
+		reader, err := zlib.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read deflate body: %w", err)
+		}
+		return reader, nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+}
+
+// isZlibHeader reports whether the two bytes start a zlib stream: deflate compression
+// method and a header checksum that divides by 31
+func isZlibHeader(header []byte) bool {
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+// declaredTotalPattern matches the site's summary line, e.g. "Всего: 4286 на 215 страницах"
+var declaredTotalPattern = regexp.MustCompile(`Всего:\s*(\d+)\s+на\s+(\d+)\s+страниц`)
+
+// detectTotalPages reads the declared number of twisters and pages from the first page.
+// If the page can't be fetched or has no summary line, fallbackPages is returned with a
+// declared total of 0.
+func detectTotalPages(ctx context.Context, firstURL string, fallbackPages int) (pages, declaredTotal int) {
+	doc, err := fetchDocument(ctx, firstURL)
+	if err != nil {
+		slog.Warn("Could not read the declared total from page 1", "assumed_pages", fallbackPages, "error", err)
+		return fallbackPages, 0
+	}
+	
+	match := declaredTotalPattern.FindStringSubmatch(doc.Text())
+	if match == nil {
+		slog.Warn("Page 1 has no \"Всего:\" line", "assumed_pages", fallbackPages)
+		return fallbackPages, 0
+	}
+	declaredTotal, _ = strconv.Atoi(match[1])
+	pages, _ = strconv.Atoi(match[2])
+	if pages < 1 {
+		pages = fallbackPages
+	}
+	return pages, declaredTotal
+}
+
+// scrapePageTwisters extracts tongue twisters and links to related twister pages from a single page,
+// waiting for the shared limiter before the request
+func scrapePageTwisters(ctx context.Context, limiter *rate.Limiter, pageURL string) ([]TongueTwister, []string, error) {
+	if err := limiter.Wait(ctx); err != nil {
+		return nil, nil, err
+	}
+	doc, err := fetchDocument(ctx, pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Some out-of-range pages come back as 200 with a generic "not found" body
+	if isNotFoundPage(doc) {
+		return nil, nil, ErrPageNotFound
+	}
 
 	var twisters []TongueTwister
+	rejected := 0
 
 	// Find all tongue twister tables
 	doc.Find("table.bgcolor4").Each(func(i int, tableSelection *goquery.Selection) {
@@ -241,65 +1210,352 @@ func scrapePageTwisters(url string) ([]TongueTwister, error) {
 		// Extract text
 		twister.Text = strings.TrimSpace(tableSelection.Find("tr.bgcolor1 td").Text())
 
-		if twister.Number != "" && twister.Text != "" {
-			twisters = append(twisters, twister)
+		// Extract attribution
+		twister.Source = pageURL
+		tableSelection.Find("small, i, em, span").EachWithBreak(func(i int, sel *goquery.Selection) bool {
+			text := strings.TrimSpace(sel.Text())
+			if strings.HasPrefix(text, "Автор:") {
+				twister.Author = strings.TrimSpace(strings.TrimPrefix(text, "Автор:"))
+				return false
+			}
+			return true
+		})
+
+		if !isValidTwister(twister) {
+			rejected++
+			slog.Debug("Rejected twister candidate", "url", pageURL, "number", twister.Number, "text", truncateRunes(twister.Text, 60))
+			return
 		}
+		twisters = append(twisters, twister)
 	})
+	
+	// Rejects pile up when the markup changes and the selectors start catching the wrong elements
+	if rejected > 0 {
+		slog.Warn("Rejected twister candidates", "url", pageURL, "rejected", rejected, "kept", len(twisters))
+	}
 
-	return twisters, nil
+	return twisters, extractRelatedLinks(doc, pageURL), nil
+}
+
+// Limits for isValidTwister
+const (
+	minTwisterRunes    = 10 // Shorter text is a cut-off row or a label, not a twister
+	minTwisterCyrillic = 3  // Twisters on the site are Russian
+)
+
+// urlPattern matches links pasted into text, which don't count towards a twister
+var urlPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// isValidTwister rejects parsed candidates that can't be real twisters: no number, text that is
+// too short, has hardly any Cyrillic letters, or is mostly digits or URLs
+func isValidTwister(twister TongueTwister) bool {
+	text := strings.TrimSpace(twister.Text)
+	if twister.Number == "" || utf8.RuneCountInString(text) < minTwisterRunes {
+		return false
+	}
+	
+	withoutURLs := strings.TrimSpace(urlPattern.ReplaceAllString(text, ""))
+	if utf8.RuneCountInString(withoutURLs)*2 < utf8.RuneCountInString(text) {
+		return false
+	}
+	
+	cyrillic, letters, digits := 0, 0, 0
+	for _, r := range withoutURLs {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+			if unicode.Is(unicode.Cyrillic, r) {
+				cyrillic++
+			}
+		case unicode.IsDigit(r):
+			digits++
+		}
+	}
+	return cyrillic >= minTwisterCyrillic && digits <= letters
+}
+
+// truncateRunes shortens text to at most n runes for log messages
+func truncateRunes(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n]) + "…"
+}
+
+// extractRelatedLinks returns the absolute URLs of related twister pages on the same host
+func extractRelatedLinks(doc *goquery.Document, pageURL string) []string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	
+	var links []string
+	seen := make(map[string]bool)
+	doc.Find(relatedLinkSelector).Each(func(i int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		ref, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			return
+		}
+		
+		link := base.ResolveReference(ref)
+		link.Fragment = ""
+		if link.Host != base.Host || (link.Scheme != "http" && link.Scheme != "https") {
+			return
+		}
+		if !seen[link.String()] {
+			seen[link.String()] = true
+			links = append(links, link.String())
+		}
+	})
+	
+	return links
+}
+
+// isNotFoundPage reports whether the document is the site's "page not found" stub
+func isNotFoundPage(doc *goquery.Document) bool {
+	// A page with twister tables is a real listing regardless of its wording
+	if doc.Find("table.bgcolor4").Length() > 0 {
+		return false
+	}
+
+	heading := strings.ToLower(doc.Find("title").Text() + " " + doc.Find("h1").Text())
+	for _, marker := range notFoundMarkers {
+		if strings.Contains(heading, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// TwisterStore is an output backend that receives every twister as it is saved
+type TwisterStore interface {
+	Save(twister TongueTwister) error
+	Close() error
+}
+
+// openStore creates the backend selected by -format
+func openStore(format, outputDir, dbPath string) (TwisterStore, error) {
+	switch format {
+	case "files":
+		return FileStore{Dir: outputDir}, nil
+	case "sqlite":
+		return NewSQLiteStore(dbPath)
+	default:
+		return nil, fmt.Errorf("invalid -format %q: expected files or sqlite", format)
+	}
+}
+
+// saveTwister stores one twister, logging instead of stopping the scrape on failure
+func saveTwister(store TwisterStore, twister TongueTwister) {
+	if err := store.Save(twister); err != nil {
+		slog.Error("Error saving tongue twister", "number", twister.Number, "error", err)
+	}
+}
+
+// FileStore writes each twister to its own text file in Dir
+type FileStore struct {
+	Dir string
+}
+
+func (s FileStore) Save(twister TongueTwister) error {
+	return saveToFile(twister, s.Dir)
+}
+
+func (s FileStore) Close() error {
+	return nil
+}
+
+// DiscardStore drops every twister; -dry-run uses it in place of the configured backend
+type DiscardStore struct{}
+
+func (DiscardStore) Save(twister TongueTwister) error {
+	return nil
+}
+
+func (DiscardStore) Close() error {
+	return nil
+}
+
+// SQLiteStore keeps twisters in the twisters table of a SQLite database, one row per number
+type SQLiteStore struct {
+	db     *sql.DB
+	upsert *sql.Stmt
+}
+
+// NewSQLiteStore opens (or creates) the database at path and makes sure the table exists
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS twisters (
+		number TEXT PRIMARY KEY,
+		date   TEXT,
+		text   TEXT
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create table in %s: %w", path, err)
+	}
+	
+	// Re-runs update the rows they scraped again instead of failing on the primary key
+	upsert, err := db.Prepare(`INSERT INTO twisters (number, date, text) VALUES (?, ?, ?)
+		ON CONFLICT(number) DO UPDATE SET date = excluded.date, text = excluded.text`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare insert in %s: %w", path, err)
+	}
+	return &SQLiteStore{db: db, upsert: upsert}, nil
+}
+
+func (s *SQLiteStore) Save(twister TongueTwister) error {
+	_, err := s.upsert.Exec(twister.Number, twister.Date, twister.Text)
+	return err
+}
+
+func (s *SQLiteStore) Close() error {
+	s.upsert.Close()
+	return s.db.Close()
+}
+
+// sanitizeFilename keeps only [0-9A-Za-z_-] from scraped text used in a file name, replacing
+// everything else (slashes, spaces, dots, Cyrillic) with "_"; the result is empty if nothing usable is left
+func sanitizeFilename(name string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if strings.Trim(b.String(), "_-") == "" {
+		return ""
+	}
+	return b.String()
 }
 
 // saveToFile saves a tongue twister to a file in the output directory
-func saveToFile(twister TongueTwister, outputDir string) {
-	// Create a clean filename
-	filename := filepath.Join(outputDir, fmt.Sprintf("twister_%s.txt", twister.Number))
+func saveToFile(twister TongueTwister, outputDir string) error {
+	// Create a clean filename; a twister without a usable number is named after its text instead
+	name := sanitizeFilename(twister.Number)
+	if name == "" {
+		name = fmt.Sprintf("t%x", sha1.Sum([]byte(twister.Text)))[:11]
+	}
+	filename := filepath.Join(outputDir, fmt.Sprintf("twister_%s.txt", name))
 	
 	// Create content with metadata
-	content := fmt.Sprintf("Number: %s\nDate: %s\n\n%s\n", 
-		twister.Number, 
-		twister.Date, 
-		twister.Text)
+	metadata := fmt.Sprintf("Number: %s\nDate: %s\n", twister.Number, twister.Date)
+	if twister.Source != "" {
+		metadata += fmt.Sprintf("Source: %s\n", twister.Source)
+	}
+	if twister.Author != "" {
+		metadata += fmt.Sprintf("Author: %s\n", twister.Author)
+	}
+	content := fmt.Sprintf("%s\n%s\n", metadata, twister.Text)
 	
 	// Write to file
-	err := os.WriteFile(filename, []byte(content), 0644)
-	if err != nil {
-		log.Printf("Error saving file %s: %v", filename, err)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to save file %s: %w", filename, err)
 	}
+	return nil
 }
 
-// saveAllToJSON saves all tongue twisters to a single JSON file
-func saveAllToJSON(twisters []TongueTwister, outputDir string) {
-	filename := filepath.Join(outputDir, "all_twisters.json")
+// saveAllToJSON saves all tongue twisters to a single JSON file named outName in the output directory
+func saveAllToJSON(twisters []TongueTwister, outputDir, outName string) {
+	filename := filepath.Join(outputDir, outName)
 	
 	// Create JSON data
 	jsonData, err := json.MarshalIndent(twisters, "", "  ")
 	if err != nil {
-		log.Printf("Error creating JSON: %v", err)
+		slog.Error("Error creating JSON", "error", err)
 		return
 	}
 	
 	// Write to file
-	err = os.WriteFile(filename, jsonData, 0644)
+	if err := writeFileAtomic(filename, jsonData); err != nil {
+		slog.Error("Error saving JSON file", "file", filename, "error", err)
+		return
+	}
+}
+
+// writeFileAtomic replaces the file in one step, so an interrupted write never leaves it half-written
+func writeFileAtomic(filename string, data []byte) error {
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// checkpointName is the file in the output directory that records scraping progress
+const checkpointName = "checkpoint.json"
+
+// Checkpoint records which pagination pages are done, so an interrupted run can resume
+type Checkpoint struct {
+	Completed          []int `json:"completed"`           // Page numbers whose twisters are saved
+	PaginationTwisters int   `json:"pagination_twisters"` // Twisters found on those pages, for the declared-total check
+}
+
+// saveCheckpoint writes the checkpoint, logging failures
+func saveCheckpoint(path string, checkpoint Checkpoint) {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
 	if err != nil {
-		log.Printf("Error saving JSON file %s: %v", filename, err)
+		slog.Error("Error creating checkpoint", "error", err)
 		return
 	}
+	if err := writeFileAtomic(path, data); err != nil {
+		slog.Error("Error saving checkpoint", "file", path, "error", err)
+	}
 }
 
-// downloadImage downloads an image from a URL and saves it to the output directory
-func downloadImage(imageURL, outputDir, filename string) error {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// loadResumeState reads the checkpoint and the combined JSON saved with it.
+// The error wraps os.ErrNotExist when there is no checkpoint to resume from.
+func loadResumeState(checkpointPath, jsonPath string) (Checkpoint, []TongueTwister, error) {
+	var checkpoint Checkpoint
+	data, err := os.ReadFile(checkpointPath)
+	if err != nil {
+		return checkpoint, nil, err
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, nil, fmt.Errorf("invalid checkpoint %s: %v", checkpointPath, err)
 	}
 	
+	twisters, err := loadTwistersJSON(jsonPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil, fmt.Errorf("checkpoint found but the saved twisters are missing: %v", err)
+	}
+	if err != nil {
+		return checkpoint, nil, err
+	}
+	return checkpoint, twisters, nil
+}
+
+// loadTwistersJSON reads a combined JSON file written by saveAllToJSON
+func loadTwistersJSON(path string) ([]TongueTwister, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var twisters []TongueTwister
+	if err := json.Unmarshal(data, &twisters); err != nil {
+		return nil, fmt.Errorf("invalid saved twisters %s: %v", path, err)
+	}
+	return twisters, nil
+}
+
+// downloadImage downloads an image from a URL and saves it to the output directory
+func downloadImage(imageURL, outputDir, filename string) error {
 	req, err := http.NewRequest("GET", imageURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+	req.Header.Set("User-Agent", randomUserAgent())
 	
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -309,7 +1565,17 @@ func downloadImage(imageURL, outputDir, filename string) error {
 		return fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
 	}
 
-	out, err := os.Create(filepath.Join(outputDir, filename))
+	// Keep the extension but nothing that could leave outputDir
+	ext := sanitizeFilename(strings.TrimPrefix(filepath.Ext(filename), "."))
+	name := sanitizeFilename(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	if name == "" {
+		return fmt.Errorf("no usable file name in %q", filename)
+	}
+	if ext != "" {
+		name += "." + ext
+	}
+
+	out, err := os.Create(filepath.Join(outputDir, name))
 	if err != nil {
 		return err
 	}
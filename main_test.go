@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// arrivalOrder returns pages 1..pages in the order results come back from concurrent
+// workers: shuffled within each window of the given size
+func arrivalOrder(r *rand.Rand, pages, window int) []int {
+	order := make([]int, pages)
+	for i := range order {
+		order[i] = i + 1
+	}
+	for start := 0; start < pages; start += window {
+		end := start + window
+		if end > pages {
+			end = pages
+		}
+		r.Shuffle(end-start, func(i, j int) {
+			order[start+i], order[start+j] = order[start+j], order[start+i]
+		})
+	}
+	return order
+}
+
+// pageResults builds one result of twistersPerPage twisters for every page
+func pageResults(pages, twistersPerPage int) map[int]PageResult {
+	results := make(map[int]PageResult, pages)
+	for page := 1; page <= pages; page++ {
+		twisters := make([]TongueTwister, twistersPerPage)
+		for i := range twisters {
+			number := strconv.Itoa((page-1)*twistersPerPage + i + 1)
+			twisters[i] = TongueTwister{Number: number, Text: "Шла Саша по шоссе и сосала сушку " + number}
+		}
+		results[page] = PageResult{PageNum: page, Twisters: twisters}
+	}
+	return results
+}
+
+func TestPageOrder(t *testing.T) {
+	completed := map[int]bool{1: true, 4: true}
+	order := NewPageOrder(completed)
+	if got := order.NextPage(); got != 2 {
+		t.Fatalf("NextPage() = %d, want 2 after skipping the completed page 1", got)
+	}
+
+	var got []int
+	for _, page := range []int{3, 6, 2, 5} {
+		order.Add(PageResult{PageNum: page})
+		for {
+			result, ok := order.Next(5)
+			if !ok {
+				break
+			}
+			got = append(got, result.PageNum)
+		}
+	}
+	want := []int{2, 3, 5}
+	if len(got) != len(want) {
+		t.Fatalf("pages handed out = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("pages handed out = %v, want %v", got, want)
+		}
+	}
+	if _, ok := order.Next(5); ok {
+		t.Error("Next(5) handed out page 6, which is past the last page")
+	}
+	if result, ok := order.Next(6); !ok || result.PageNum != 6 {
+		t.Errorf("Next(6) = %d, %v, want page 6 once the last page is raised", result.PageNum, ok)
+	}
+}
+
+// BenchmarkPageOrder feeds a corpus the size of the site (215 pages of 20 twisters) through
+// the ordering in the order five workers would return it. RescanFromFirstPage is the loop
+// PageOrder replaced, which walked pages 1..lastPage again after every result.
+func BenchmarkPageOrder(b *testing.B) {
+	const pages = 215
+	results := pageResults(pages, 20)
+	arrival := arrivalOrder(rand.New(rand.NewSource(1)), pages, 5)
+
+	b.Run("PageOrder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			order := NewPageOrder(make(map[int]bool))
+			handled := 0
+			for _, page := range arrival {
+				order.Add(results[page])
+				for {
+					if _, ok := order.Next(pages); !ok {
+						break
+					}
+					handled++
+				}
+			}
+			if handled != pages {
+				b.Fatalf("handled %d pages, want %d", handled, pages)
+			}
+		}
+	})
+
+	b.Run("RescanFromFirstPage", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			completed := make(map[int]bool)
+			resultsByPage := make(map[int]PageResult)
+			handled := 0
+			for _, page := range arrival {
+				resultsByPage[page] = results[page]
+				for page := 1; page <= pages; page++ {
+					if !completed[page] && resultsByPage[page].Twisters != nil {
+						completed[page] = true
+						handled++
+					} else if !completed[page] {
+						break
+					}
+				}
+			}
+			if handled != pages {
+				b.Fatalf("handled %d pages, want %d", handled, pages)
+			}
+		}
+	})
+}
+
+func TestThrottleWaitCancelled(t *testing.T) {
+	throttle := NewThrottle(2, 0, 0)
+	throttle.active = 1 // worker 2 is paused
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() { done <- throttle.Wait(ctx, 2) }()
+	cancel()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Wait on a cancelled context reported the worker may go on")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return after the context was cancelled")
+	}
+
+	if !throttle.Wait(context.Background(), 1) {
+		t.Error("Wait paused an active worker")
+	}
+}
+
+func TestFetchDocumentGonePage(t *testing.T) {
+	for _, code := range []int{http.StatusNotFound, http.StatusGone} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(code)
+		}))
+		_, err := fetchDocument(context.Background(), server.URL)
+		server.Close()
+
+		if !errors.Is(err, ErrPageGone) || errors.Is(err, ErrPageNotFound) {
+			t.Errorf("status %d: err = %v, want ErrPageGone and not ErrPageNotFound", code, err)
+		}
+		if isTransient(err) {
+			t.Errorf("status %d: isTransient(%v) = true, want false", code, err)
+		}
+	}
+}
+
+func TestDecodeBodyDeflate(t *testing.T) {
+	const page = "<html><body>Всего: 4286 на 215 страницах</body></html>"
+
+	var wrapped, raw bytes.Buffer
+	zw := zlib.NewWriter(&wrapped)
+	zw.Write([]byte(page))
+	zw.Close()
+	fw, _ := flate.NewWriter(&raw, flate.DefaultCompression)
+	fw.Write([]byte(page))
+	fw.Close()
+
+	for name, body := range map[string][]byte{"zlib": wrapped.Bytes(), "raw": raw.Bytes()} {
+		resp := &http.Response{
+			Header: http.Header{"Content-Encoding": {"deflate"}},
+			Body:   io.NopCloser(bytes.NewReader(body)),
+		}
+		reader, err := decodeBody(resp)
+		if err != nil {
+			t.Fatalf("%s: decodeBody: %v", name, err)
+		}
+		got, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil || string(got) != page {
+			t.Errorf("%s: body = %q, %v; want %q", name, got, err, page)
+		}
+	}
+}